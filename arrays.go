@@ -0,0 +1,217 @@
+package deepcopy
+
+import (
+	"reflect"
+	"sort"
+)
+
+// region is a backing array of the original, copied once,
+// so the slices sharing it and the pointers to its elements share its copy.
+// It spans the capacity of the first slice found on it, or the part of it a slice reused by CopyInto holds.
+type region struct {
+	start, end uintptr
+	elem       *plan
+	// copy is a slice over the copy of the array, as long as its capacity.
+	copy reflect.Value
+	// track is true when the elements are not primitive, so their copies are tracked to be made once.
+	track bool
+	// lo and hi bound the elements claimed first, which are often all of the claimed ones,
+	// and claimed marks the elements that are copied, or being copied, once others are claimed.
+	lo, hi  int
+	claimed []bool
+}
+
+// regions indexes the copied backing arrays, which never overlap, by their addresses.
+// Level i holds either none or 1<<i regions, sorted by address, so adding one moves every region log n times at most.
+type regions struct {
+	levels [][]*region
+}
+
+// find returns the region holding the address, if any.
+func (rs *regions) find(ptr uintptr) *region {
+	for _, level := range rs.levels {
+		i := sort.Search(len(level), func(i int) bool { return level[i].start > ptr }) - 1
+		if i >= 0 && level[i].end > ptr {
+			return level[i]
+		}
+	}
+	return nil
+}
+
+// overlaps reports whether a region starts between start and end, excluding start.
+func (rs *regions) overlaps(start, end uintptr) bool {
+	for _, level := range rs.levels {
+		i := sort.Search(len(level), func(i int) bool { return level[i].start > start })
+		if i < len(level) && level[i].start < end {
+			return true
+		}
+	}
+	return false
+}
+
+func (rs *regions) add(r *region) {
+	carry := []*region{r}
+	for i := range rs.levels {
+		if len(rs.levels[i]) == 0 {
+			rs.levels[i] = carry
+			return
+		}
+		carry = mergeRegions(rs.levels[i], carry)
+		rs.levels[i] = nil
+	}
+	rs.levels = append(rs.levels, carry)
+}
+
+// mergeRegions merges the sorted regions a and b.
+func mergeRegions(a, b []*region) []*region {
+	merged := make([]*region, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0].start < b[0].start {
+			merged, a = append(merged, a[0]), a[1:]
+		} else {
+			merged, b = append(merged, b[0]), b[1:]
+		}
+	}
+	return append(append(merged, a...), b...)
+}
+
+// region returns the region the slice ov is on, adding it if the backing array was not found before,
+// and the offset of the slice in the region.
+// It returns nil for a slice that cannot share the copy of its array:
+// an empty one, or one reaching past the region found before it.
+func (c *copier) region(ov reflect.Value, p *plan) (*region, int, error) {
+	size := p.elem.typ.Size()
+	if size == 0 || ov.Cap() == 0 {
+		return nil, 0, nil
+	}
+	start := ov.Pointer()
+	end := start + uintptr(ov.Cap())*size
+	if c.shards != nil {
+		c.arraysMu.Lock()
+		defer c.arraysMu.Unlock()
+	}
+	if r := c.arrays.find(start); r != nil {
+		if r.elem.typ != p.elem.typ || end > r.end || (start-r.start)%size != 0 {
+			return nil, 0, nil
+		}
+		return r, int((start - r.start) / size), nil
+	}
+	if c.arrays.overlaps(start, end) {
+		return nil, 0, nil
+	}
+	if err := c.alloc(end - start); err != nil {
+		return nil, 0, err
+	}
+	r := &region{start: start, end: end, elem: p.elem, copy: reflect.MakeSlice(reflect.SliceOf(p.elem.typ), ov.Cap(), ov.Cap())}
+	r.track = !p.elem.primitive || c.opts.transform != nil
+	c.arrays.add(r)
+	return r, 0, nil
+}
+
+// reuse adds the region of the slice ov, whose copy is the slice oc CopyInto reuses, with the elements of ov claimed,
+// so the slices on the same backing array and the pointers to its elements share oc as far as its capacity goes.
+func (c *copier) reuse(ov, oc reflect.Value, p *plan) {
+	size := p.elem.typ.Size()
+	n := min(ov.Cap(), oc.Cap())
+	if size == 0 || n == 0 {
+		return
+	}
+	start := ov.Pointer()
+	end := start + uintptr(n)*size
+	if c.sources.only(start, start+uintptr(ov.Cap())*size) {
+		// nothing else of the source references the backing array
+		return
+	}
+	if c.shards != nil {
+		c.arraysMu.Lock()
+		defer c.arraysMu.Unlock()
+	}
+	if c.arrays.find(start) != nil || c.arrays.overlaps(start, end) {
+		return
+	}
+	r := &region{start: start, end: end, elem: p.elem, copy: oc.Slice3(0, n, n), hi: ov.Len()}
+	if t := reflect.SliceOf(p.elem.typ); r.copy.Type() != t {
+		r.copy = r.copy.Convert(t)
+	}
+	r.track = !p.elem.primitive || c.opts.transform != nil
+	c.arrays.add(r)
+}
+
+// element returns the region holding the element the pointer ov points to, and its index, if there is one.
+func (c *copier) element(ov reflect.Value, p *plan) (*region, int) {
+	size := p.elem.typ.Size()
+	if size == 0 {
+		return nil, 0
+	}
+	ptr := ov.Pointer()
+	if c.shards != nil {
+		c.arraysMu.Lock()
+		defer c.arraysMu.Unlock()
+	}
+	r := c.arrays.find(ptr)
+	if r == nil || r.elem.typ != p.elem.typ || (ptr-r.start)%size != 0 {
+		return nil, 0
+	}
+	return r, int((ptr - r.start) / size)
+}
+
+// claim marks the first run of elements of r between from and limit that are not copied yet as copied,
+// and returns its bounds, which are equal when there is none.
+func (c *copier) claim(r *region, from, limit int) (int, int) {
+	if c.shards != nil {
+		c.arraysMu.Lock()
+		defer c.arraysMu.Unlock()
+	}
+	if r.claimed == nil {
+		switch {
+		case r.lo == r.hi:
+			r.lo, r.hi = from, limit
+			return from, limit
+		case from >= r.lo && limit <= r.hi:
+			return limit, limit
+		}
+		r.claimed = make([]bool, r.copy.Len())
+		for i := r.lo; i < r.hi; i++ {
+			r.claimed[i] = true
+		}
+	}
+	for from < limit && r.claimed[from] {
+		from++
+	}
+	to := from
+	for to < limit && !r.claimed[to] {
+		r.claimed[to] = true
+		to++
+	}
+	return from, to
+}
+
+// fillRegion copies the elements of the slice ov, found at offset in the region r, into oc, its copy in r,
+// unless they were copied already.
+func (c *copier) fillRegion(r *region, oc, ov reflect.Value, offset int) error {
+	if !r.track {
+		// primitive elements are copied again, as it costs less than keeping track of them
+		if c.shards != nil {
+			c.arraysMu.Lock()
+			defer c.arraysMu.Unlock()
+		}
+		reflect.Copy(oc, ov)
+		return nil
+	}
+	for at, n := offset, offset+ov.Len(); ; {
+		from, to := c.claim(r, at, n)
+		if from == to {
+			return nil
+		}
+		var err error
+		if c.parallel(to - from) {
+			err = c.copyElemsParallel(oc, ov, r.elem, from-offset, to-offset)
+		} else {
+			err = c.copyElems(oc, ov, r.elem, from-offset, to-offset)
+		}
+		if err != nil {
+			return err
+		}
+		at = to
+	}
+}
@@ -0,0 +1,799 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// marker is the comment that selects a type for generation.
+const marker = "//deepcopy:generate"
+
+// runtimePath is the import path of the package generated code falls back to.
+const runtimePath = "github.com/gadumitrachioaiei/deepcopy"
+
+// helpers is the code the generated methods share, which records the references copied
+// so that the ones shared in the original are shared in the copy and its cycles end, like with deepcopy.Copy.
+const helpers = `// deepcopyRefs maps the pointers, maps and backing arrays of the original to their copies.
+type deepcopyRefs map[interface{}]interface{}
+
+// deepcopyKey is the key in deepcopyRefs of the map of type T at the address ptr,
+// or of the deepcopyArrays of type T when ptr is zero.
+type deepcopyKey[T any] struct {
+	ptr uintptr
+}
+
+// deepcopyArray is the copy of the backing array of the original at the address start,
+// of which the elements marked as filled are copied, or being copied.
+// It spans the capacity of the first slice found on the array.
+type deepcopyArray[E any] struct {
+	start  uintptr
+	elems  []E
+	filled []bool
+}
+
+// deepcopyArrays are the copies of the backing arrays of elements of type E, sorted by address,
+// so the slices of a backing array and the pointers to its elements share its copy.
+type deepcopyArrays[E any] []*deepcopyArray[E]
+
+// find returns the index of the array holding the address ptr, or of the first one after it,
+// and reports whether it holds it. The elements have the size size.
+func (as deepcopyArrays[E]) find(ptr, size uintptr) (int, bool) {
+	lo, hi := 0, len(as)
+	for lo < hi {
+		if m := (lo + hi) / 2; as[m].start > ptr {
+			hi = m
+		} else {
+			lo = m + 1
+		}
+	}
+	if lo > 0 && ptr < as[lo-1].start+uintptr(len(as[lo-1].elems))*size {
+		return lo - 1, true
+	}
+	return lo, false
+}
+
+// deepcopyElems are the elements of a slice of the original, found at offset in the copy of its backing array,
+// if it has one.
+type deepcopyElems[E any] struct {
+	array  *deepcopyArray[E]
+	offset int
+}
+
+// fill reports whether the element i of the slice is not copied yet, and marks it as copied.
+func (e deepcopyElems[E]) fill(i int) bool {
+	if e.array == nil {
+		return true
+	}
+	if e.array.filled[e.offset+i] {
+		return false
+	}
+	e.array.filled[e.offset+i] = true
+	return true
+}
+
+// deepcopyPointer sets *out to the copy of the pointer in and reports whether the value it points to
+// is not copied yet, so the caller copies it.
+// A pointer to an element of a copied backing array points to the copy of the element.
+func deepcopyPointer[T any](refs deepcopyRefs, out **T, in *T) bool {
+	if c, ok := refs[in]; ok {
+		*out = c.(*T)
+		return false
+	}
+	if size := reflect.TypeOf(in).Elem().Size(); size > 0 {
+		as, _ := refs[deepcopyKey[[]T]{}].(deepcopyArrays[T])
+		ptr := reflect.ValueOf(in).Pointer()
+		if i, ok := as.find(ptr, size); ok {
+			e := deepcopyElems[T]{as[i], int((ptr - as[i].start) / size)}
+			*out = &e.array.elems[e.offset]
+			refs[in] = *out
+			return e.fill(0)
+		}
+	}
+	*out = new(T)
+	refs[in] = *out
+	return true
+}
+
+// deepcopyMap sets *out to the copy of the map in and reports whether it is a new one,
+// whose entries the caller copies.
+func deepcopyMap[M ~map[K]V, K comparable, V any](refs deepcopyRefs, out *M, in M) bool {
+	key := deepcopyKey[M]{reflect.ValueOf(in).Pointer()}
+	if c, ok := refs[key]; ok {
+		*out = c.(M)
+		return false
+	}
+	*out = make(M, len(in))
+	refs[key] = *out
+	return true
+}
+
+// deepcopySlice sets *out to the copy of the slice in and returns its elements, of which the caller copies the ones to fill.
+// The slices of a backing array share its copy, unless they reach past it or over the copy of another one.
+// The elements of in must not be of size zero, as they could not tell the backing arrays apart.
+func deepcopySlice[S ~[]E, E any](refs deepcopyRefs, out *S, in S) deepcopyElems[E] {
+	if cap(in) == 0 {
+		*out = make(S, len(in))
+		return deepcopyElems[E]{}
+	}
+	key := deepcopyKey[[]E]{}
+	as, _ := refs[key].(deepcopyArrays[E])
+	size := reflect.TypeOf(in).Elem().Size()
+	start := reflect.ValueOf(in).Pointer()
+	end := start + uintptr(cap(in))*size
+	i, ok := as.find(start, size)
+	switch {
+	case ok && end <= as[i].start+uintptr(len(as[i].elems))*size:
+		e := deepcopyElems[E]{as[i], int((start - as[i].start) / size)}
+		*out = S(e.array.elems[e.offset : e.offset+len(in) : e.offset+cap(in)])
+		return e
+	case ok || i < len(as) && as[i].start < end:
+		*out = make(S, len(in), cap(in))
+		return deepcopyElems[E]{}
+	}
+	a := &deepcopyArray[E]{start: start, elems: make([]E, cap(in)), filled: make([]bool, cap(in))}
+	as = append(as, nil)
+	copy(as[i+1:], as[i:])
+	as[i] = a
+	refs[key] = as
+	*out = S(a.elems[:len(in)])
+	return deepcopyElems[E]{array: a}
+}
+
+// deepcopyCall sets *out to the copy the function copy makes of the pointer or the map in,
+// unless one was made before.
+func deepcopyCall[T any](refs deepcopyRefs, out *T, in T, copy func() T) {
+	key := deepcopyKey[T]{reflect.ValueOf(in).Pointer()}
+	if c, ok := refs[key]; ok {
+		*out = c.(T)
+		return
+	}
+	*out = copy()
+	refs[key] = *out
+}
+`
+
+// helperNames are the names declared by helpers.
+var helperNames = []string{"deepcopyRefs", "deepcopyKey", "deepcopyArray", "deepcopyArrays", "deepcopyElems", "deepcopyPointer", "deepcopyMap", "deepcopySlice", "deepcopyCall"}
+
+// generate returns the source of the file with the copy methods of the named types, and of the marked types,
+// of the package in dir. The file named output is ignored, as it holds the previously generated methods.
+func generate(dir string, names []string, output string) ([]byte, error) {
+	pkg, files, err := load(dir, output)
+	if err != nil {
+		return nil, err
+	}
+	named, err := selectTypes(pkg, files, names)
+	if err != nil {
+		return nil, err
+	}
+	if len(named) == 0 {
+		return nil, errors.New("no types to generate, use -type or a " + marker + " comment")
+	}
+	g := &generator{
+		pkg:       pkg,
+		imports:   make(map[string]string),
+		generated: make(map[*types.Named]bool),
+		inlining:  make(map[*types.Named]bool),
+		assign:    make(map[types.Type]bool),
+	}
+	for _, t := range named {
+		g.generated[t] = true
+	}
+	for _, t := range named {
+		if err := g.methods(t); err != nil {
+			return nil, err
+		}
+	}
+	g.imports["reflect"] = "reflect"
+	g.buf.WriteString(helpers)
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by deepcopy-gen. DO NOT EDIT.\n\npackage %s\n\n", pkg.Name())
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		out.WriteString("import (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+		out.WriteString(")\n\n")
+	}
+	out.Write(g.buf.Bytes())
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+// load parses and type checks the package in dir, without its tests and the file named exclude.
+func load(dir, exclude string) (*types.Package, []*ast.File, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		if name == exclude {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(bp.Dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, f)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check(bp.ImportPath, fset, files, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pkg, files, nil
+}
+
+// selectTypes returns the named types and the marked types, in this order and without duplicates.
+func selectTypes(pkg *types.Package, files []*ast.File, names []string) ([]*types.Named, error) {
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if hasMarker(ts.Doc) || len(gd.Specs) == 1 && hasMarker(gd.Doc) {
+					names = append(names, ts.Name.Name)
+				}
+			}
+		}
+	}
+	for _, name := range helperNames {
+		if pkg.Scope().Lookup(name) != nil {
+			return nil, fmt.Errorf("%s is declared by the package and by the generated code", name)
+		}
+	}
+	var named []*types.Named
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok || obj.IsAlias() {
+			return nil, fmt.Errorf("%s is not a defined type of package %s", name, pkg.Name())
+		}
+		t := obj.Type().(*types.Named)
+		if t.TypeParams().Len() > 0 {
+			return nil, fmt.Errorf("%s: generic types are not supported", name)
+		}
+		switch t.Underlying().(type) {
+		case *types.Pointer, *types.Interface:
+			return nil, fmt.Errorf("%s: pointer and interface types cannot have methods", name)
+		}
+		for _, m := range []string{"DeepCopy", "DeepCopyInto", "deepCopyInto"} {
+			if obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), false, pkg, m); obj != nil {
+				return nil, fmt.Errorf("%s already has a %s method or field", name, m)
+			}
+		}
+		named = append(named, t)
+	}
+	return named, nil
+}
+
+func hasMarker(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// generator writes the copy methods of a package.
+//
+// The code copying a value is written for an addressable source expression and an addressable destination expression,
+// which holds the zero value.
+// Nested values are reached by rebinding the in and out variables to pointers to them, like the method receiver,
+// so the expressions stay short and the loop variables can be reused.
+type generator struct {
+	pkg *types.Package
+	buf bytes.Buffer
+	// imports maps the paths of the imported packages to their names.
+	imports map[string]string
+	// generated are the types the methods are generated for.
+	generated map[*types.Named]bool
+	// inlining are the types whose copy is being written inline, to detect recursive types.
+	inlining map[*types.Named]bool
+	// assign caches whether assigning a value of a type is a deep copy.
+	assign map[types.Type]bool
+	err    error
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// fail records the first error found while generating.
+func (g *generator) fail(format string, args ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf(format, args...)
+	}
+}
+
+// methods writes the DeepCopyInto and DeepCopy methods of t,
+// and the deepCopyInto method they call, which records the references it copies in refs.
+func (g *generator) methods(t *types.Named) error {
+	name := t.Obj().Name()
+	if isLock(t) {
+		return fmt.Errorf("%s: a lock cannot be copied", name)
+	}
+	g.printf("// DeepCopyInto copies the receiver into out, which must not be nil.\n")
+	g.printf("func (in *%s) DeepCopyInto(out *%s) {\n", name, name)
+	// the code copying nested values relies on their destination being zero, like a new value,
+	// so only the receiver needs to be cleared
+	switch t.Underlying().(type) {
+	case *types.Struct:
+		g.printf("*out = %s{}\n", name)
+	case *types.Slice, *types.Map:
+		g.printf("*out = nil\n")
+	}
+	// out is the copy of the receiver, for the values pointing back to it
+	g.printf("in.deepCopyInto(out, deepcopyRefs{in: out})\n}\n\n")
+	g.printf("// DeepCopy returns a deep copy of the receiver.\n")
+	g.printf("func (in *%s) DeepCopy() *%s {\n", name, name)
+	g.printf("if in == nil {\nreturn nil\n}\n")
+	g.printf("out := new(%s)\nin.DeepCopyInto(out)\nreturn out\n}\n\n", name)
+	g.printf("func (in *%s) deepCopyInto(out *%s, refs deepcopyRefs) {\n", name, name)
+	g.inlining[t] = true
+	g.copyInline("*out", "*in", t)
+	delete(g.inlining, t)
+	g.printf("}\n\n")
+	if g.err != nil {
+		return fmt.Errorf("%s: %w", name, g.err)
+	}
+	return nil
+}
+
+// copy writes the code setting dst to a deep copy of src, of type t.
+func (g *generator) copy(dst, src string, t types.Type) {
+	if isLock(t) {
+		// a lock is left zero
+		return
+	}
+	if g.assignable(t) {
+		g.printf("%s = %s\n", dst, src)
+		return
+	}
+	if named, ok := t.(*types.Named); ok && g.generated[named] {
+		g.printf("%s.deepCopyInto(%s, refs)\n", recv(src), addr(dst))
+		return
+	}
+	_, isPointer := t.Underlying().(*types.Pointer)
+	_, isMap := t.Underlying().(*types.Map)
+	switch kind, elem := copyMethod(t); kind {
+	case valueMethod:
+		if isPointer || isMap {
+			// the copies made by the method are shared like the pointers and maps copied
+			g.printf("if %s != nil {\ndeepcopyCall(refs, %s, %s, %s.%s)\n}\n", src, addr(dst), src, recv(src), elem)
+			return
+		}
+		g.printf("%s = %s.%s()\n", dst, recv(src), elem)
+		return
+	case pointerMethod:
+		g.printf("if c := %s.%s(); c != nil {\n%s = *c\n}\n", recv(src), elem, dst)
+		return
+	case intoMethod:
+		g.printf("%s.DeepCopyInto(%s)\n", recv(src), addr(dst))
+		return
+	case pointerIntoMethod:
+		g.printf("if %s != nil && deepcopyPointer(refs, %s, %s) {\n%s.DeepCopyInto(%s)\n}\n", src, addr(dst), src, recv(src), dst)
+		return
+	}
+	if named, ok := t.(*types.Named); ok {
+		if g.inlining[named] {
+			// the copy of a recursive type cannot be written inline
+			g.runtime(dst, src)
+			return
+		}
+		g.inlining[named] = true
+		defer delete(g.inlining, named)
+	}
+	g.copyInline(dst, src, t)
+}
+
+// copyInline writes the code copying src to dst according to the underlying type of t,
+// without calling any copy method of t.
+func (g *generator) copyInline(dst, src string, t types.Type) {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		if u.Kind() == types.Uintptr || u.Kind() == types.UnsafePointer {
+			g.fail("unsupported type %s", t)
+			return
+		}
+		g.printf("%s = %s\n", dst, src)
+	case *types.Chan, *types.Signature:
+		g.printf("%s = %s\n", dst, src)
+	case *types.Pointer:
+		g.printf("if %s != nil {\n", src)
+		if isLock(u.Elem()) {
+			// a new lock is all the copy needs
+			g.printf("deepcopyPointer(refs, %s, %s)\n}\n", addr(dst), src)
+			return
+		}
+		g.rebind(dst, src)
+		g.printf("if deepcopyPointer(refs, out, *in) {\n")
+		g.copy("**out", "**in", u.Elem())
+		g.printf("}\n}\n")
+	case *types.Slice:
+		g.printf("if %s != nil {\n", src)
+		g.rebind(dst, src)
+		switch {
+		case sizes.Sizeof(u.Elem()) == 0:
+			// the elements have no address telling the backing arrays apart
+			g.printf("*out = make(%s, len(*in), cap(*in))\n", g.typeString(t))
+		case isLock(u.Elem()):
+			g.printf("deepcopySlice(refs, out, *in)\n")
+		case g.assignable(u.Elem()):
+			g.printf("deepcopySlice(refs, out, *in)\ncopy(*out, *in)\n")
+		default:
+			g.printf("elems := deepcopySlice(refs, out, *in)\nfor i := range *in {\nif elems.fill(i) {\n")
+			g.copy("(*out)[i]", "(*in)[i]", u.Elem())
+			g.printf("}\n}\n")
+		}
+		g.printf("}\n")
+	case *types.Array:
+		if isLock(u.Elem()) {
+			return
+		}
+		g.printf("{\n")
+		g.rebind(dst, src)
+		g.printf("for i := range *in {\n")
+		g.copy("(*out)[i]", "(*in)[i]", u.Elem())
+		g.printf("}\n}\n")
+	case *types.Map:
+		if isLock(u.Key()) || isLock(u.Elem()) {
+			// a zero lock cannot be stored without copying one
+			g.runtime(dst, src)
+			return
+		}
+		g.printf("if %s != nil {\n", src)
+		g.rebind(dst, src)
+		g.printf("if deepcopyMap(refs, out, *in) {\n")
+		g.printf("for key, val := range *in {\n")
+		key, val := "key", "val"
+		if !g.assignable(u.Key()) {
+			key = "newKey"
+			g.printf("var newKey %s\n", g.typeString(u.Key()))
+			g.copy(key, "key", u.Key())
+		}
+		if !g.assignable(u.Elem()) {
+			val = "newVal"
+			g.printf("var newVal %s\n", g.typeString(u.Elem()))
+			g.copy(val, "val", u.Elem())
+		}
+		g.printf("(*out)[%s] = %s\n", key, val)
+		g.printf("}\n}\n}\n")
+	case *types.Struct:
+		g.copyStruct(dst, src, u)
+	case *types.Interface:
+		// the dynamic type is only known at run time
+		g.runtime(dst, src)
+	default:
+		g.fail("unsupported type %s", t)
+	}
+}
+
+// copyStruct writes the code copying the struct src to dst, field by field.
+// The fields that are not copied are left zero.
+func (g *generator) copyStruct(dst, src string, st *types.Struct) {
+	for i := 0; i < st.NumFields(); i++ {
+		if f := st.Field(i); !f.Exported() && parseTag(st.Tag(i)) != tagNone && f.Pkg() != g.pkg {
+			// the tag asks for copying a field this package cannot reach
+			g.runtime(dst, src)
+			return
+		}
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		mode := parseTag(st.Tag(i))
+		if mode == tagSkip || !f.Exported() && mode == tagNone || isLock(f.Type()) {
+			continue
+		}
+		fdst, fsrc := selector(dst, f.Name()), selector(src, f.Name())
+		switch mode {
+		case tagShallow:
+			g.printf("%s = %s\n", fdst, fsrc)
+		case tagDeep:
+			g.copyForced(fdst, fsrc, f.Type())
+		default:
+			g.copy(fdst, fsrc, f.Type())
+		}
+	}
+}
+
+// copyForced writes the code deep copying src to dst even if its kind is normally shared, as asked by the deep tag.
+func (g *generator) copyForced(dst, src string, t types.Type) {
+	switch u := t.Underlying().(type) {
+	case *types.Signature:
+		g.fail("%s: a function cannot be deep copied", src)
+	case *types.Chan:
+		if u.Dir() != types.SendRecv {
+			g.fail("%s: a directional channel cannot be deep copied by generated code", src)
+			return
+		}
+		// receiving and sending back every buffered element keeps their order
+		g.printf("if %s != nil {\n", src)
+		g.rebind(dst, src)
+		g.printf("*out = make(%s, cap(*in))\n", g.typeString(t))
+		g.printf("for n := len(*in); n > 0; n-- {\ne := <-*in\n*in <- e\n")
+		if g.assignable(u.Elem()) {
+			g.printf("*out <- e\n")
+		} else {
+			g.printf("var newE %s\n", g.typeString(u.Elem()))
+			g.copy("newE", "e", u.Elem())
+			g.printf("*out <- newE\n")
+		}
+		g.printf("}\n}\n")
+	default:
+		g.copy(dst, src, t)
+	}
+}
+
+// rebind writes the code binding in and out to pointers to src and dst.
+func (g *generator) rebind(dst, src string) {
+	in, out := addr(src), addr(dst)
+	if in != "in" || out != "out" {
+		g.printf("in, out := %s, %s\n", in, out)
+	}
+}
+
+// runtime writes the code copying src to dst with the deepcopy package.
+func (g *generator) runtime(dst, src string) {
+	g.imports[runtimePath] = "deepcopy"
+	g.printf("%s = deepcopy.MustClone(%s)\n", dst, src)
+}
+
+// assignable reports whether assigning a value of type t is a deep copy, as deepcopy.Copy makes it.
+func (g *generator) assignable(t types.Type) bool {
+	if v, ok := g.assign[t]; ok {
+		return v
+	}
+	// values of recursive types hold references
+	g.assign[t] = false
+	v := g.isAssignable(t)
+	g.assign[t] = v
+	return v
+}
+
+func (g *generator) isAssignable(t types.Type) bool {
+	if named, ok := t.(*types.Named); ok {
+		if obj := named.Obj(); obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time" {
+			return true
+		}
+		if g.generated[named] {
+			return false
+		}
+	}
+	if kind, _ := copyMethod(t); kind != noMethod || isLock(t) {
+		return false
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Kind() != types.Uintptr && u.Kind() != types.UnsafePointer
+	case *types.Chan, *types.Signature:
+		return true
+	case *types.Array:
+		return g.assignable(u.Elem())
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if !u.Field(i).Exported() || parseTag(u.Tag(i)) != tagNone || !g.assignable(u.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		g.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
+
+// methodKind says how a type copies itself, following the rules of deepcopy.Copy.
+type methodKind int
+
+const (
+	noMethod methodKind = iota
+	// valueMethod is a DeepCopy() T or Clone() T method.
+	valueMethod
+	// pointerMethod is a DeepCopy() *T or Clone() *T method of *T, for a non pointer T.
+	pointerMethod
+	// intoMethod is a DeepCopyInto(*T) method of *T, for a non pointer T.
+	intoMethod
+	// pointerIntoMethod is a DeepCopyInto(T) method of T, for a pointer T.
+	pointerIntoMethod
+)
+
+// copyMethod returns the kind and the name of the method values of type t copy themselves with.
+func copyMethod(t types.Type) (methodKind, string) {
+	if types.IsInterface(t) {
+		return noMethod, ""
+	}
+	if returnsOwnType(t, "DeepCopy") {
+		return valueMethod, "DeepCopy"
+	}
+	if _, ok := t.Underlying().(*types.Pointer); ok {
+		if copiesInto(t) {
+			return pointerIntoMethod, "DeepCopyInto"
+		}
+		if returnsOwnType(t, "Clone") {
+			return valueMethod, "Clone"
+		}
+		return noMethod, ""
+	}
+	pt := types.NewPointer(t)
+	switch {
+	case returnsOwnType(pt, "DeepCopy"):
+		return pointerMethod, "DeepCopy"
+	case copiesInto(pt):
+		return intoMethod, "DeepCopyInto"
+	case returnsOwnType(t, "Clone"):
+		return valueMethod, "Clone"
+	case returnsOwnType(pt, "Clone"):
+		return pointerMethod, "Clone"
+	}
+	return noMethod, ""
+}
+
+// syncLocks are the names of the lock types of package sync.
+var syncLocks = map[string]bool{"Mutex": true, "RWMutex": true, "WaitGroup": true, "Once": true, "Cond": true}
+
+// isLock reports whether t is a lock, which deepcopy.Copy leaves zero: a lock type of package sync,
+// or a type whose pointer has Lock and Unlock methods while it has none, and which holds nothing else:
+// a zero size marker or a struct whose fields are all locks.
+func isLock(t types.Type) bool {
+	if types.IsInterface(t) {
+		return false
+	}
+	if named, ok := t.(*types.Named); ok {
+		if obj := named.Obj(); obj.Pkg() != nil && obj.Pkg().Path() == "sync" {
+			return syncLocks[obj.Name()]
+		}
+	}
+	if !implementsLocker(types.NewPointer(t)) || method(t, "Lock") != nil {
+		return false
+	}
+	if sizes.Sizeof(t) == 0 {
+		return true
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok || st.NumFields() == 0 {
+		return false
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if !isLock(st.Field(i).Type()) {
+			return false
+		}
+	}
+	return true
+}
+
+// sizes are the sizes of the types on the target architecture.
+var sizes = types.SizesFor("gc", build.Default.GOARCH)
+
+// implementsLocker reports whether t implements sync.Locker.
+func implementsLocker(t types.Type) bool {
+	for _, name := range []string{"Lock", "Unlock"} {
+		obj, _, _ := types.LookupFieldOrMethod(t, false, nil, name)
+		f, ok := obj.(*types.Func)
+		if !ok {
+			return false
+		}
+		if sig := f.Type().(*types.Signature); sig.Params().Len() != 0 || sig.Results().Len() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// method returns the signature of the method name in the method set of t, or nil.
+func method(t types.Type, name string) *types.Signature {
+	obj, _, _ := types.LookupFieldOrMethod(t, false, nil, name)
+	if f, ok := obj.(*types.Func); ok {
+		return f.Type().(*types.Signature)
+	}
+	return nil
+}
+
+// returnsOwnType reports whether t has the method name, with no arguments and returning a t.
+func returnsOwnType(t types.Type, name string) bool {
+	sig := method(t, name)
+	return sig != nil && sig.Params().Len() == 0 && sig.Results().Len() == 1 && types.Identical(sig.Results().At(0).Type(), t)
+}
+
+// copiesInto reports whether the pointer type t has a DeepCopyInto method taking a t and returning nothing.
+func copiesInto(t types.Type) bool {
+	sig := method(t, "DeepCopyInto")
+	return sig != nil && sig.Params().Len() == 1 && types.Identical(sig.Params().At(0).Type(), t) && sig.Results().Len() == 0
+}
+
+// tagMode is the way a struct field is copied, as set by its deepcopy tag.
+type tagMode int
+
+const (
+	tagNone tagMode = iota
+	tagSkip
+	tagShallow
+	tagDeep
+)
+
+// parseTag returns the copy mode of a struct field with the tag, the same way deepcopy.Copy does.
+func parseTag(tag string) tagMode {
+	mode := tagNone
+	for _, opt := range strings.Split(reflect.StructTag(tag).Get("deepcopy"), ",") {
+		switch opt {
+		case "-":
+			mode = tagSkip
+		case "shallow":
+			mode = tagShallow
+		case "deep":
+			mode = tagDeep
+		}
+	}
+	// redact only matters to deepcopy.WithRedaction, and name to deepcopy.CopyTo,
+	// so the generated methods copy such fields like untagged ones
+	return mode
+}
+
+// addr returns the expression of the address of the addressable expression x.
+func addr(x string) string {
+	if strings.HasPrefix(x, "*") {
+		return x[1:]
+	}
+	return "&" + x
+}
+
+// recv returns the expression x, ready to have a method called on it.
+func recv(x string) string {
+	if strings.HasPrefix(x, "*") {
+		return "(" + x + ")"
+	}
+	return x
+}
+
+// selector returns the expression selecting the field name of the struct expression x.
+func selector(x, name string) string {
+	if strings.HasPrefix(x, "*") {
+		// a field of a pointer to struct is selected through the pointer
+		x = x[1:]
+		if strings.HasPrefix(x, "*") {
+			x = "(" + x + ")"
+		}
+	}
+	return x + "." + name
+}
@@ -0,0 +1,64 @@
+// Command deepcopy-gen writes DeepCopy and DeepCopyInto methods for the types of a package,
+// so they can be deep copied without reflection.
+//
+// The generated methods copy the same way deepcopy.Copy does with its default options:
+// unexported fields and locks are left zero, channels and functions are shared, time.Time is copied by value,
+// the deepcopy struct tags are honored and DeepCopy, DeepCopyInto and Clone methods of other types are called.
+// Interface values are copied with deepcopy.MustClone, as their dynamic type is only known at run time,
+// and so are values of types the generator cannot copy on its own,
+// like recursive types that are not generated or structs of other packages with tagged unexported fields.
+// Like deepcopy.Copy, the generated methods keep the pointers, maps and backing arrays shared in the original
+// shared in the copy, and so copy cycles. Slices starting at different elements of a backing array,
+// and pointers to its elements, share its copy as well. Unlike with deepcopy.Copy, the values copied
+// with deepcopy.MustClone share no references with the rest of the copy.
+//
+// Usage:
+//
+//	deepcopy-gen [-type T1,T2] [-o zz_generated.deepcopy.go] [dir]
+//
+// The types are the ones listed by -type and the ones whose declaration is preceded by a
+// //deepcopy:generate comment. The package is the one in dir, by default the current directory.
+// It is meant to be used with go generate:
+//
+//	//go:generate deepcopy-gen -type Config
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma separated list of type names")
+	output := flag.String("o", "zz_generated.deepcopy.go", "output file name, relative to the package directory")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: deepcopy-gen [-type T1,T2] [-o file] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+	out := filepath.Join(dir, *output)
+	src, err := generate(dir, names, filepath.Base(out))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "deepcopy-gen:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "deepcopy-gen:", err)
+		os.Exit(1)
+	}
+}
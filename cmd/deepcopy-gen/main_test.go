@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenerateGolden checks the generated code of the test package is up to date.
+func TestGenerateGolden(t *testing.T) {
+	dir := filepath.Join("..", "..", "internal", "gentest")
+	const output = "zz_generated.deepcopy.go"
+	got, err := generate(dir, nil, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile(filepath.Join(dir, output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(expected) {
+		t.Fatalf("generated code differs from %s, run go generate in %s:\n%s", output, dir, got)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		{"//deepcopy:generate\ntype T struct{ P uintptr }", "unsupported type uintptr"},
+		{"//deepcopy:generate\ntype T struct{ F func() `deepcopy:\"deep\"` }", "a function cannot be deep copied"},
+		{"//deepcopy:generate\ntype T struct{ C <-chan int `deepcopy:\"deep\"` }", "directional channel"},
+		{"//deepcopy:generate\ntype T interface{}", "cannot have methods"},
+		{"//deepcopy:generate\ntype T struct{}\n\nfunc (T) DeepCopy() T { return T{} }", "already has a DeepCopy method"},
+		{"//deepcopy:generate\ntype T struct{}\n\nfunc (*T) Lock()   {}\nfunc (*T) Unlock() {}", "a lock cannot be copied"},
+		{"//deepcopy:generate\ntype T struct{}\n\ntype deepcopyRefs int", "declared by the package and by the generated code"},
+		{"type T struct{}", "no types to generate"},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		src := "package p\n\n" + test.src + "\n"
+		if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := generate(dir, nil, "zz_generated.deepcopy.go")
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("%s: got error: %v, expected it to contain: %s", test.src, err, test.err)
+		}
+	}
+}
+
+// TestGenerateLockerWithData checks a type guarding its data with its own Lock and Unlock methods is not a lock.
+func TestGenerateLockerWithData(t *testing.T) {
+	dir := t.TempDir()
+	src := `package p
+
+import "sync"
+
+//deepcopy:generate
+type T struct {
+	mu   sync.Mutex
+	Data map[string]int
+}
+
+func (t *T) Lock()   { t.mu.Lock() }
+func (t *T) Unlock() { t.mu.Unlock() }
+`
+	if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := generate(dir, nil, "zz_generated.deepcopy.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "in.Data") {
+		t.Fatalf("expected the data to be copied:\n%s", got)
+	}
+}
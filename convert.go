@@ -0,0 +1,291 @@
+package deepcopy
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// CopyTo deep copies src into the value dst points to, which may have another type than src.
+// Struct fields are matched by name, or by the name set with the name tag option, like deepcopy:"name=Other",
+// on either side. Only exported fields are matched, the fields with no match in src are left zero,
+// and a field skipped by its tag on either side is not copied.
+// The other tags of the source field apply, or else the ones of the destination field,
+// and a field redacted by WithRedaction is converted once redacted.
+// Values are converted between the kinds that convert without loss:
+// integers to integers and floats to floats that hold their value exactly, e.g. int32 to int64,
+// or float64 to float32 for a value with no more precision than a float32 has,
+// strings and bools of different types, *T to T and T to *T,
+// slices and arrays to slices and arrays of convertible elements, as long as a destination array can hold them all,
+// maps to maps of convertible keys and elements, and values to the interfaces they implement.
+// Values of the same type are copied following the same rules as Copy.
+// A value that cannot be converted causes a *ConversionError.
+// dst must be a non nil pointer.
+func CopyTo(dst, src interface{}, opts ...Option) error {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return fmt.Errorf("deepcopy: CopyTo destination must be a non nil pointer, not %T", dst)
+	}
+	c := &converter{copier: newCopier(opts), pairs: make(map[[2]reflect.Type][]fieldPair)}
+	oc, err := c.convert(reflect.ValueOf(src), dv.Type().Elem())
+	if err != nil {
+		return err
+	}
+	dv.Elem().Set(oc)
+	return nil
+}
+
+// converter holds the state of one CopyTo.
+// The copier copies the values of the same type, and remembers the converted pointers, so they stay shared.
+type converter struct {
+	*copier
+	// pairs caches the matching fields of a source and a destination struct type.
+	pairs map[[2]reflect.Type][]fieldPair
+}
+
+// fieldPair is a source field and the destination field it is copied to.
+type fieldPair struct {
+	src, dst *fieldPlan
+}
+
+// convert returns a deep copy of ov, converted to the type t.
+func (c *converter) convert(ov reflect.Value, t reflect.Type) (reflect.Value, error) {
+	if !ov.IsValid() {
+		return reflect.Zero(t), nil
+	}
+	ot := ov.Type()
+	if ot == t {
+		return c.copyPlan(ov, planFor(t))
+	}
+	switch {
+	case ot.Kind() == reflect.Interface:
+		if ov.IsNil() {
+			return reflect.Zero(t), nil
+		}
+		return c.convert(ov.Elem(), t)
+	case t.Kind() == reflect.Interface && ot.Implements(t):
+		// checked before dereferencing a pointer, which may be what implements t
+		oc, err := c.copyr(ov)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ic := reflect.New(t).Elem()
+		ic.Set(oc)
+		return ic, nil
+	case t.Kind() == reflect.Ptr:
+		return c.convertPointer(ov, t)
+	case ot.Kind() == reflect.Ptr:
+		if ov.IsNil() {
+			return reflect.Zero(t), nil
+		}
+		return c.convert(ov.Elem(), t)
+	case ot.Kind() == reflect.Struct && t.Kind() == reflect.Struct:
+		return c.convertStruct(ov, t)
+	case (ot.Kind() == reflect.Slice || ot.Kind() == reflect.Array) && t.Kind() == reflect.Slice:
+		if ot.Kind() == reflect.Slice && ov.IsNil() {
+			return reflect.Zero(t), nil
+		}
+		oc := reflect.MakeSlice(t, ov.Len(), ov.Len())
+		return oc, c.convertElems(oc, ov, ov.Len())
+	case (ot.Kind() == reflect.Slice || ot.Kind() == reflect.Array) && t.Kind() == reflect.Array:
+		if ov.Len() > t.Len() {
+			reason := fmt.Sprintf("%d elements do not fit in %d", ov.Len(), t.Len())
+			return reflect.Value{}, &ConversionError{From: ot, To: t, Path: c.path.String(), Reason: reason}
+		}
+		oc := reflect.New(t).Elem()
+		return oc, c.convertElems(oc, ov, ov.Len())
+	case ot.Kind() == reflect.Map && t.Kind() == reflect.Map:
+		return c.convertMap(ov, t)
+	case scalar(ot.Kind()) != 0 && scalar(ot.Kind()) == scalar(t.Kind()) && ot.ConvertibleTo(t):
+		if !exact(ov, t) {
+			return reflect.Value{}, &ConversionError{From: ot, To: t, Path: c.path.String(), Reason: "value changes when converted"}
+		}
+		return ov.Convert(t), nil
+	}
+	return reflect.Value{}, &ConversionError{From: ot, To: t, Path: c.path.String(), Reason: "incompatible types"}
+}
+
+func (c *converter) convertPointer(ov reflect.Value, t reflect.Type) (reflect.Value, error) {
+	if ov.Kind() != reflect.Ptr {
+		ec, err := c.convert(ov, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		oc := reflect.New(t.Elem())
+		oc.Elem().Set(ec)
+		return oc, nil
+	}
+	if ov.IsNil() {
+		return reflect.Zero(t), nil
+	}
+	// the key has the destination type, so a pointer converted to several types is converted to each of them
+	key := visit{ptr: ov.Pointer(), typ: t}
+	if oc, ok := c.seen(key); ok {
+		return oc, nil
+	}
+	// we register the copy before converting the element, so cycles end up here
+	oc, _ := c.register(key, reflect.New(t.Elem()))
+	ec, err := c.convert(ov.Elem(), t.Elem())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	oc.Elem().Set(ec)
+	return oc, nil
+}
+
+func (c *converter) convertStruct(ov reflect.Value, t reflect.Type) (reflect.Value, error) {
+	oc := reflect.New(t).Elem()
+	for _, pair := range c.fieldPairs(ov.Type(), t) {
+		fv := ov.Field(pair.src.index)
+		mode := c.fieldMode(pair.src, fv)
+		if mode == tagSkip {
+			continue
+		}
+		if mode == tagNone {
+			// the tag of the destination field counts as well
+			mode = c.fieldMode(pair.dst, fv)
+		}
+		c.path.push(PathStep{Kind: FieldStep, Name: pair.src.name})
+		var fc reflect.Value
+		var tagged bool
+		var err error
+		if pair.src.plan.typ != pair.dst.plan.typ {
+			if mode == tagRedact {
+				// the redacted value is what is converted, so the original never reaches the destination
+				fv = c.redact(fv)
+			}
+			fc, err = c.convert(fv, pair.dst.plan.typ)
+		} else if fc, tagged, err = c.copyTagged(fv, pair.src.plan, mode); !tagged {
+			fc, err = c.copyPlan(fv, pair.src.plan)
+		}
+		c.path.pop()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		oc.Field(pair.dst.index).Set(fc)
+	}
+	return oc, nil
+}
+
+// fieldPairs returns the matching exported fields of the struct types from and to.
+func (c *converter) fieldPairs(from, to reflect.Type) []fieldPair {
+	types := [2]reflect.Type{from, to}
+	if pairs, ok := c.pairs[types]; ok {
+		return pairs
+	}
+	fromPlan, toPlan := planFor(from), planFor(to)
+	fields := make(map[string]*fieldPlan)
+	for i := range fromPlan.fields {
+		if f := &fromPlan.fields[i]; f.exported && f.mode != tagSkip {
+			fields[matchName(f)] = f
+		}
+	}
+	var pairs []fieldPair
+	for i := range toPlan.fields {
+		f := &toPlan.fields[i]
+		if !f.exported || f.mode == tagSkip {
+			continue
+		}
+		if src, ok := fields[matchName(f)]; ok {
+			pairs = append(pairs, fieldPair{src: src, dst: f})
+		}
+	}
+	c.pairs[types] = pairs
+	return pairs
+}
+
+// matchName returns the name CopyTo matches the field f by.
+func matchName(f *fieldPlan) string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// convertElems converts the first n elements of the slice or array ov into the ones of oc.
+func (c *converter) convertElems(oc, ov reflect.Value, n int) error {
+	for i := 0; i < n; i++ {
+		c.path.push(PathStep{Kind: IndexStep, Index: i})
+		ec, err := c.convert(ov.Index(i), oc.Type().Elem())
+		c.path.pop()
+		if err != nil {
+			return err
+		}
+		oc.Index(i).Set(ec)
+	}
+	return nil
+}
+
+func (c *converter) convertMap(ov reflect.Value, t reflect.Type) (reflect.Value, error) {
+	if ov.IsNil() {
+		return reflect.Zero(t), nil
+	}
+	oc := reflect.MakeMapWithSize(t, ov.Len())
+	iter := ov.MapRange()
+	for iter.Next() {
+		c.path.push(PathStep{Kind: KeyStep, Key: iter.Key()})
+		redacted := c.redacted
+		kc, err := c.convert(iter.Key(), t.Key())
+		if err != nil || c.redacted != redacted {
+			c.path.pop()
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			// the entry of a redacted key is left out, like in a copy
+			continue
+		}
+		ec, err := c.convert(iter.Value(), t.Elem())
+		c.path.pop()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		oc.SetMapIndex(kc, ec)
+	}
+	return oc, nil
+}
+
+// scalar returns the class of the kind k, the kinds of the same class converting into one another,
+// or 0 for the kinds that are not scalar.
+func scalar(k reflect.Kind) int {
+	switch k {
+	case reflect.Bool:
+		return 1
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return 2
+	case reflect.Float32, reflect.Float64:
+		return 3
+	case reflect.Complex64, reflect.Complex128:
+		return 4
+	case reflect.String:
+		return 5
+	}
+	return 0
+}
+
+// exact reports whether the scalar ov keeps its value when converted to t, of the same class.
+// A float is kept if it converts back to the same value, so rounding to a lower precision is refused like an overflow.
+func exact(ov reflect.Value, t reflect.Type) bool {
+	zero := reflect.Zero(t)
+	switch {
+	case ov.CanInt() && zero.CanInt():
+		return !zero.OverflowInt(ov.Int())
+	case ov.CanInt():
+		return ov.Int() >= 0 && !zero.OverflowUint(uint64(ov.Int()))
+	case ov.CanUint() && zero.CanInt():
+		return ov.Uint() <= math.MaxInt64 && !zero.OverflowInt(int64(ov.Uint()))
+	case ov.CanUint():
+		return !zero.OverflowUint(ov.Uint())
+	case ov.CanFloat():
+		return sameFloat(ov.Convert(t).Float(), ov.Float())
+	case ov.CanComplex():
+		c, o := ov.Convert(t).Complex(), ov.Complex()
+		return sameFloat(real(c), real(o)) && sameFloat(imag(c), imag(o))
+	}
+	return true
+}
+
+// sameFloat reports whether a and b are the same value, NaN being the same as itself.
+func sameFloat(a, b float64) bool {
+	return a == b || math.IsNaN(a) && math.IsNaN(b)
+}
@@ -0,0 +1,185 @@
+package deepcopy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type status string
+
+type order struct {
+	ID       int32
+	Customer *customer
+	Lines    []line
+	Totals   map[string]int32
+	Status   status
+	Created  time.Time
+	Notes    [2]string
+	Internal string `deepcopy:"-"`
+	Tags     []string
+	Owner    fmt.Stringer
+	version  int
+}
+
+type customer struct {
+	Name    string
+	Manager *customer
+}
+
+type line struct {
+	SKU string
+	Qty uint8
+}
+
+type orderDTO struct {
+	OrderID  int64 `deepcopy:"name=ID"`
+	Customer customerDTO
+	Lines    []*lineDTO
+	Totals   map[string]int64
+	Status   string
+	Created  time.Time
+	Notes    []string
+	Internal string
+	Tags     []string `deepcopy:"shallow"`
+	Owner    interface{}
+	Missing  *int
+}
+
+type customerDTO struct {
+	Name    string
+	Manager *customerDTO
+}
+
+type lineDTO struct {
+	Product string `deepcopy:"name=SKU"`
+	Qty     int
+}
+
+func TestCopyTo(t *testing.T) {
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	manager := &customer{Name: "m"}
+	manager.Manager = manager
+	o := &order{
+		ID:       1,
+		Customer: &customer{Name: "c", Manager: manager},
+		Lines:    []line{{SKU: "a", Qty: 2}, {SKU: "b", Qty: 3}},
+		Totals:   map[string]int32{"net": 10},
+		Status:   "open",
+		Created:  created,
+		Notes:    [2]string{"x", "y"},
+		Internal: "internal",
+		Tags:     []string{"t"},
+		Owner:    created,
+		version:  1,
+	}
+	var dto orderDTO
+	if err := CopyTo(&dto, o); err != nil {
+		t.Fatal(err)
+	}
+	m := &customerDTO{Name: "m"}
+	m.Manager = m
+	expected := orderDTO{
+		OrderID:  1,
+		Customer: customerDTO{Name: "c", Manager: m},
+		Lines:    []*lineDTO{{Product: "a", Qty: 2}, {Product: "b", Qty: 3}},
+		Totals:   map[string]int64{"net": 10},
+		Status:   "open",
+		Created:  created,
+		Notes:    []string{"x", "y"},
+		Tags:     []string{"t"},
+		Owner:    created,
+	}
+	if !reflect.DeepEqual(dto, expected) {
+		t.Fatalf("got: %+v, expected: %+v", dto, expected)
+	}
+	if dto.Customer.Manager.Manager != dto.Customer.Manager || &dto.Tags[0] != &o.Tags[0] {
+		t.Fatal("expected the cycle to be reproduced and the shallow field to be shared")
+	}
+
+	// and back again
+	var back order
+	if err := CopyTo(&back, dto); err != nil {
+		t.Fatal(err)
+	}
+	o.Internal, o.version = "", 0
+	if !reflect.DeepEqual(&back, o) {
+		t.Fatalf("got: %+v, expected: %+v", back, o)
+	}
+
+	// a float held exactly by a float32 and a slice shorter than the array convert
+	var narrow struct {
+		F float32
+		A [3]int
+	}
+	wide := struct {
+		F float64
+		A []int
+	}{0.5, []int{1, 2}}
+	if err := CopyTo(&narrow, wide); err != nil || narrow.F != 0.5 || narrow.A != [3]int{1, 2} {
+		t.Fatalf("got: %+v, error: %v, expected the values to be converted", narrow, err)
+	}
+
+	// a pointer implementing the interface is copied as it is, not dereferenced
+	var r struct{ R io.Reader }
+	buf := bytes.NewBufferString("b")
+	if err := CopyTo(&r, struct{ R *bytes.Buffer }{buf}, WithUnexported(UnexportedDeep)); err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := r.R.(*bytes.Buffer); !ok || b == buf || b.String() != "b" {
+		t.Fatalf("got: %#v, expected a copy of the buffer", r.R)
+	}
+}
+
+func TestCopyToErrors(t *testing.T) {
+	tests := []struct {
+		dst, src interface{}
+		path     string
+	}{
+		{new(struct{ A int8 }), struct{ A int }{300}, ".A"},
+		{new(struct{ A uint }), struct{ A int }{-1}, ".A"},
+		{new(struct{ A string }), struct{ A int }{1}, ".A"},
+		{new([]int), []float64{1}, "[0]"},
+		{new(struct{ A float32 }), struct{ A float64 }{0.1}, ".A"},
+		{new(struct{ A float32 }), struct{ A float64 }{math.MaxFloat64}, ".A"},
+		{new(struct{ A complex64 }), struct{ A complex128 }{complex(1, 0.1)}, ".A"},
+		{new(struct{ A [1]int }), struct{ A []int }{[]int{1, 2}}, ".A"},
+		{new(struct{ A [1]int }), struct{ A [2]int32 }{}, ".A"},
+		{new(map[string]fmt.Stringer), map[string]int{"a": 1}, `["a"]`},
+	}
+	for _, test := range tests {
+		err := CopyTo(test.dst, test.src)
+		var cerr *ConversionError
+		if !errors.As(err, &cerr) || cerr.Path != test.path {
+			t.Fatalf("%T to %T: got error: %v, expected a *ConversionError at %s", test.src, test.dst, err, test.path)
+		}
+	}
+	if err := CopyTo(struct{}{}, struct{}{}); err == nil {
+		t.Fatal("expected an error for a destination that is not a pointer")
+	}
+}
+
+func TestCopyToRedaction(t *testing.T) {
+	type from struct {
+		Secret string `deepcopy:"redact"`
+		Status string `deepcopy:"redact"`
+		Token  string
+	}
+	type to struct {
+		Secret *string
+		Status status
+		Token  string `deepcopy:"redact"`
+	}
+	var dst to
+	if err := CopyTo(&dst, from{Secret: "hunter2", Status: "s", Token: "t"}, WithRedaction("***")); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Secret == nil || *dst.Secret != "***" || dst.Status != "***" || dst.Token != "***" {
+		t.Fatalf("got: %+v, expected the fields tagged on either side to be redacted", dst)
+	}
+}
@@ -0,0 +1,103 @@
+package deepcopy
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// CopierFunc returns a copy of a value of the type it was registered for.
+// The returned value must be assignable to the type of the copied value.
+type CopierFunc func(reflect.Value) (reflect.Value, error)
+
+// copiers holds the registered copiers.
+var copiers = struct {
+	sync.RWMutex
+	types map[reflect.Type]CopierFunc
+	// interfaces are kept in registration order, which is the order they are tried in.
+	interfaces []interfaceCopier
+}{types: make(map[reflect.Type]CopierFunc)}
+
+type interfaceCopier struct {
+	typ reflect.Type
+	f   CopierFunc
+}
+
+func init() {
+	RegisterCopier(reflect.TypeOf(time.Time{}), copyTime)
+}
+
+// RegisterCopier registers f to copy all values of type t, instead of the default deep copy.
+// If t is an interface type, f copies all values whose type implements t.
+// A copier registered for the exact type wins over interface copiers,
+// which are tried in the order they were registered.
+// Registering a copier for a type again replaces the previous one.
+// Nil pointers, maps, slices and functions are copied as nil, without calling f.
+// It is safe to call RegisterCopier concurrently with Copy, but copies
+// already in progress might not see the new copier.
+func RegisterCopier(t reflect.Type, f CopierFunc) {
+	copiers.Lock()
+	defer copiers.Unlock()
+	if t.Kind() == reflect.Interface {
+		for i, ic := range copiers.interfaces {
+			if ic.typ == t {
+				copiers.interfaces[i].f = f
+				plans.Store(new(sync.Map))
+				return
+			}
+		}
+		copiers.interfaces = append(copiers.interfaces, interfaceCopier{typ: t, f: f})
+	} else {
+		copiers.types[t] = f
+	}
+	// the plans compiled so far might miss the new copier
+	plans.Store(new(sync.Map))
+}
+
+// copierFor returns the copier registered for t, or nil if there is none.
+// The caller must hold the read lock of copiers.
+func copierFor(t reflect.Type) CopierFunc {
+	f := copiers.types[t]
+	if f == nil && t.Kind() != reflect.Interface {
+		for _, ic := range copiers.interfaces {
+			if t.Implements(ic.typ) {
+				f = ic.f
+				break
+			}
+		}
+	}
+	return f
+}
+
+// copyCustom copies ov using the copier f.
+func (c *copier) copyCustom(ov reflect.Value, f CopierFunc) (reflect.Value, error) {
+	var key visit
+	switch ov.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func:
+		if ov.IsNil() {
+			return ov, nil
+		}
+	}
+	switch ov.Kind() {
+	case reflect.Ptr, reflect.Map:
+		key = visit{ptr: ov.Pointer(), typ: ov.Type()}
+		if oc, ok := c.seen(key); ok {
+			return oc, nil
+		}
+	}
+	oc, err := f(ov)
+	if err != nil {
+		return reflect.Value{}, &CopierError{Type: ov.Type(), Path: c.path.String(), Err: err}
+	}
+	if !oc.IsValid() || !oc.Type().AssignableTo(ov.Type()) {
+		return reflect.Value{}, &CopierError{Type: ov.Type(), Path: c.path.String(), Err: errInvalidCopy}
+	}
+	if key.typ != nil {
+		oc, _ = c.register(key, oc)
+	}
+	return oc, nil
+}
+
+func copyTime(ov reflect.Value) (reflect.Value, error) {
+	return ov, nil
+}
@@ -0,0 +1,96 @@
+package deepcopy
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type handle struct {
+	ID int
+}
+
+type opaque interface {
+	Opaque() string
+}
+
+type secret struct {
+	Value string
+}
+
+func (s *secret) Opaque() string { return s.Value }
+
+type failing struct{ N int }
+
+type wrongType struct{ N int }
+
+func init() {
+	RegisterCopier(reflect.TypeOf(handle{}), func(ov reflect.Value) (reflect.Value, error) {
+		h := ov.Interface().(handle)
+		return reflect.ValueOf(handle{ID: h.ID + 1000}), nil
+	})
+	RegisterCopier(reflect.TypeOf((*opaque)(nil)).Elem(), func(ov reflect.Value) (reflect.Value, error) {
+		return ov, nil
+	})
+	RegisterCopier(reflect.TypeOf(failing{}), func(ov reflect.Value) (reflect.Value, error) {
+		return reflect.Value{}, errors.New("failing copier")
+	})
+	RegisterCopier(reflect.TypeOf(wrongType{}), func(ov reflect.Value) (reflect.Value, error) {
+		return reflect.ValueOf(1), nil
+	})
+}
+
+func TestCopyRegisteredType(t *testing.T) {
+	type S struct {
+		H  handle
+		HS []handle
+	}
+	vi, err := Copy(S{H: handle{ID: 1}, HS: []handle{{ID: 2}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := vi.(S)
+	if v.H.ID != 1001 || v.HS[0].ID != 1002 {
+		t.Fatalf("got: %+v, expected the registered copier to be used", v)
+	}
+}
+
+func TestCopyRegisteredInterface(t *testing.T) {
+	type S struct {
+		O opaque
+		P *secret
+		Q *secret
+	}
+	s := &secret{Value: "s"}
+	vi, err := Copy(S{O: s, P: s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := vi.(S)
+	if v.O != opaque(s) || v.P != s {
+		t.Fatalf("got: %+v, expected the registered copier to share the secret", v)
+	}
+	if v.Q != nil {
+		t.Fatalf("got: %+v, expected nil", v.Q)
+	}
+}
+
+func TestCopyRegisteredFailing(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		path string
+	}{
+		{struct{ F []failing }{F: []failing{{N: 1}}}, ".F[0]"},
+		{map[string]wrongType{"a": {}}, `["a"]`},
+	}
+	for _, test := range tests {
+		_, err := Copy(test.v)
+		var ce *CopierError
+		if !errors.As(err, &ce) {
+			t.Fatalf("got error: %v, expected *CopierError", err)
+		}
+		if ce.Path != test.path {
+			t.Fatalf("got path: %s, expected: %s", ce.Path, test.path)
+		}
+	}
+}
@@ -560,6 +560,145 @@ type PointerLocker struct {
 func (p *PointerLocker) Lock()   { p.Mu.Lock() }
 func (p *PointerLocker) Unlock() { p.Mu.Unlock() }
 
+// copyWaits checks that copy does not finish until unlock is called.
+func copyWaits(t *testing.T, unlock func(), copy func() (interface{}, error)) interface{} {
+	t.Helper()
+	type copied struct {
+		v   interface{}
+		err error
+	}
+	done := make(chan copied, 1)
+	go func() {
+		v, err := copy()
+		done <- copied{v, err}
+	}()
+	select {
+	case <-done:
+		t.Fatal("the copy did not wait for the lock")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	c := <-done
+	if c.err != nil {
+		t.Fatalf("err: %s", c.err)
+	}
+	return c.v
+}
+
+func TestCopy_lockedField(t *testing.T) {
+	v := &LockedField{
+		String: "orig",
+		Locker: &EmbeddedLocker{
+			Map: map[int]int{42: 1},
+		},
+	}
+
+	v.Locker.Lock()
+	result := copyWaits(t, v.Locker.Unlock, func() (interface{}, error) {
+		return Copy(v, LockSource())
+	})
+
+	if !reflect.DeepEqual(result, v) {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	// the locks of the original and of the copy are unlocked
+	v.Locker.Lock()
+	result.(*LockedField).Locker.Lock()
+}
+
+func TestCopy_missingLockedField(t *testing.T) {
+	v := &LockedField{
+		String: "orig",
+	}
+
+	result, err := Copy(v, LockSource())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual(result, v) {
+		t.Fatalf("bad: %#v", result)
+	}
+}
+
+func TestCopy_lockedMap(t *testing.T) {
+	v := lockedMap{1: 2}
+
+	mapLock.Lock()
+	result := copyWaits(t, mapLock.Unlock, func() (interface{}, error) {
+		return Copy(v, LockSource())
+	})
+
+	if !reflect.DeepEqual(result, v) {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	mapLock.Lock()
+	mapLock.Unlock()
+}
+
+func TestCopy_rLocker(t *testing.T) {
+	v := &RLocker{
+		Map: map[int]int{1: 2},
+	}
+
+	// a read lock does not keep the copy from read locking
+	v.RLock()
+	result, err := Copy(v, LockSource())
+	v.RUnlock()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(result, v) {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	v.Lock()
+	copyWaits(t, v.Unlock, func() (interface{}, error) {
+		return Copy(v, LockSource())
+	})
+}
+
+func TestCopy_pointerLocker(t *testing.T) {
+	v := &struct {
+		P   *PointerLocker
+		Nil *PointerLocker
+	}{
+		P: &PointerLocker{},
+	}
+
+	// PointerLocker holds nothing but its lock, so it is a lock itself and is reset rather than locked
+	v.P.Lock()
+	result, err := Copy(v, LockSource())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	v.P.Unlock()
+
+	if !reflect.DeepEqual(result, v) {
+		t.Fatalf("bad: %#v", result)
+	}
+}
+
+func TestCopyInto_lockedField(t *testing.T) {
+	v := &LockedField{
+		Locker: &EmbeddedLocker{
+			Map: map[int]int{42: 1},
+		},
+	}
+	dst := &LockedField{Locker: &EmbeddedLocker{}}
+
+	v.Locker.Lock()
+	copyWaits(t, v.Locker.Unlock, func() (interface{}, error) {
+		return nil, CopyInto(&dst, v, LockSource())
+	})
+
+	if !reflect.DeepEqual(dst, v) {
+		t.Fatalf("bad: %#v", dst)
+	}
+}
+
 func TestCopy_sliceWithNil(t *testing.T) {
 	v := [](*int){nil}
 
@@ -2,127 +2,784 @@
 package deepcopy
 
 import (
-	"errors"
-	"fmt"
+	"context"
 	"reflect"
+	"regexp"
+	"runtime"
+	"sync"
+	"unsafe"
 )
 
 // Copy returns a deepcopy of the specified object.
-// Unexported fields of a struct are ignored and will not be copied.
-// The types unsafe.Pointer and uintptr are not supported and they will cause a panic.
-// A channel will point to original channel.
-// Error will always be nil, and it is here just for compatibility with copystructure package.
-func Copy(o interface{}) (interface{}, error) {
-	return copyr(reflect.ValueOf(o)).Interface(), nil
+// Values whose type, or pointer to type, has a DeepCopy() T, DeepCopyInto(*T) or Clone() T method
+// are copied by calling that method, unless IgnoreMethods is given.
+// Unexported fields of a struct are ignored and will not be copied, unless WithUnexported says otherwise.
+// The types unsafe.Pointer and uintptr are not supported and they will cause an *UnsupportedTypeError.
+// A channel will point to original channel, unless WithChan says otherwise.
+// A function is shared with the original, unless WithFunc says otherwise.
+// Pointers, maps and slices that are shared in the original are shared in the copy as well,
+// and cycles are reproduced.
+// Slices on the same backing array, like s and s[1:], share the copy of the array,
+// and so do the pointers to its elements, like &s[0].
+// The array is copied as far as the capacity of the first slice found on it, though,
+// so a slice reaching further, or a pointer to an element found before any slice, is copied apart.
+// Locks, like sync.Mutex, are left zero in the copy, unless WithSync says otherwise.
+// Copying nil returns nil.
+func Copy(o interface{}, opts ...Option) (interface{}, error) {
+	if o == nil {
+		return nil, nil
+	}
+	c := newCopier(opts)
+	oc, err := c.copyr(reflect.ValueOf(o))
+	if err != nil {
+		return nil, err
+	}
+	return oc.Interface(), nil
+}
+
+// CopyContext is like Copy, but gives up when ctx is done, returning ctx.Err().
+// The context is checked every few hundred copied values, so a long copy stops shortly after.
+func CopyContext(ctx context.Context, o interface{}, opts ...Option) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return nil, nil
+	}
+	c := newCopier(opts)
+	c.ctx = ctx
+	oc, err := c.copyr(reflect.ValueOf(o))
+	if err != nil {
+		return nil, err
+	}
+	return oc.Interface(), nil
+}
+
+// Clone returns a deepcopy of v, following the same rules as Copy.
+// Unlike Copy, it keeps the static type of v, so there is no need for a type assertion.
+func Clone[T any](v T, opts ...Option) (T, error) {
+	var oc T
+	c := newCopier(opts)
+	ov := reflect.ValueOf(&v).Elem()
+	if ov.Kind() != reflect.Interface {
+		// v is a copy made by the call, it must not be locked by LockSource like the caller's value
+		ov = reflect.ValueOf(v)
+	}
+	cv, err := c.copyr(ov)
+	if err != nil {
+		return oc, err
+	}
+	reflect.ValueOf(&oc).Elem().Set(cv)
+	return oc, nil
+}
+
+// MustClone is like Clone but panics if v cannot be copied.
+func MustClone[T any](v T, opts ...Option) T {
+	oc, err := Clone(v, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return oc
+}
+
+// copier holds the state of one deep copy.
+// A parallel copy uses a copier per goroutine, all of them sharing the same copyState.
+type copier struct {
+	*copyState
+	// path leads from the root to the value being copied.
+	path Path
+	// depth is the nesting of the value being copied, measured against the limits.
+	depth int
+	// ticks counts the copied values, so the context is checked every checkEvery ticks.
+	ticks int
+	// redacted counts the redacted values, so the map entries whose key is redacted are left out.
+	redacted int
+}
+
+// copyState is the state of one deep copy shared by all its goroutines.
+type copyState struct {
+	opts options
+	// ctx is the context of CopyContext.
+	ctx context.Context
+	// visited maps already copied references to their copies.
+	visited map[visit]reflect.Value
+	// shards replace visited in a parallel copy, so goroutines rarely wait for each other.
+	shards []shard
+	// chans serializes the copies of channels in a parallel copy, as they receive from and send to the original.
+	chans sync.Mutex
+	// arrays holds the copied backing arrays of slices, guarded by arraysMu in a parallel copy.
+	arrays   regions
+	arraysMu sync.Mutex
+	// reused holds the references of the destination of CopyInto that were already reused,
+	// so they are not overwritten by two different values.
+	reused map[visit]bool
+	// sources holds the memory the source of CopyInto references, which is never reused.
+	sources *spans
+	// nodes and bytes are measured against the limits. They are updated atomically.
+	nodes int64
+	bytes uint64
+	// slots holds a token for every goroutine a parallel copy runs besides the calling one.
+	// It is nil for a sequential copy.
+	slots chan struct{}
+}
+
+// checkEvery is the number of copied values between two checks of the context.
+const checkEvery = 256
+
+// tick accounts for a copied value, and returns the error of the context when it is time to check it.
+func (c *copier) tick() error {
+	if c.ctx == nil {
+		return nil
+	}
+	c.ticks++
+	if c.ticks%checkEvery != 0 {
+		return nil
+	}
+	return c.ctx.Err()
+}
+
+// shard holds part of the visited references of a parallel copy.
+type shard struct {
+	sync.Mutex
+	visited map[visit]reflect.Value
+}
+
+// shardCount is the number of shards of a parallel copy, a power of two.
+const shardCount = 64
+
+// shard returns the shard holding the reference identified by key.
+func (c *copier) shard(key visit) *shard {
+	// values are at least 8 bytes apart, so the lowest bits do not tell them apart
+	return &c.shards[key.ptr>>3&(shardCount-1)]
+}
+
+// seen returns the copy of the reference identified by key, if it was already copied.
+func (c *copier) seen(key visit) (reflect.Value, bool) {
+	if c.shards != nil {
+		s := c.shard(key)
+		s.Lock()
+		defer s.Unlock()
+		oc, ok := s.visited[key]
+		return oc, ok
+	}
+	oc, ok := c.visited[key]
+	return oc, ok
+}
+
+// register records oc as the copy of the reference identified by key and reports whether it did.
+// In a parallel copy another goroutine may have registered a copy first, which is returned instead.
+func (c *copier) register(key visit, oc reflect.Value) (reflect.Value, bool) {
+	if c.shards != nil {
+		s := c.shard(key)
+		s.Lock()
+		defer s.Unlock()
+		if prev, ok := s.visited[key]; ok {
+			return prev, false
+		}
+		s.visited[key] = oc
+		return oc, true
+	}
+	c.visited[key] = oc
+	return oc, true
+}
+
+// visit identifies a reference value that was already copied.
+// Slices are identified by their whole header, so subslices of the same array are copied independently.
+type visit struct {
+	ptr      uintptr
+	typ      reflect.Type
+	len, cap int
+}
+
+func newCopier(opts []Option) *copier {
+	state := &copyState{opts: newOptions(opts)}
+	// the locks taken by LockSource could deadlock the goroutines of a parallel copy
+	if state.opts.workers > 1 && !state.opts.lockSource {
+		state.slots = make(chan struct{}, state.opts.workers-1)
+		state.shards = make([]shard, shardCount)
+		for i := range state.shards {
+			state.shards[i].visited = make(map[visit]reflect.Value)
+		}
+	} else {
+		state.visited = make(map[visit]reflect.Value)
+	}
+	return &copier{copyState: state}
 }
 
 // copyr deep copies a reflect value.
-// We intentionally specify all supported types, so we panic for all unsupported.
-func copyr(ov reflect.Value) reflect.Value {
+func (c *copier) copyr(ov reflect.Value) (reflect.Value, error) {
 	if !ov.IsValid() {
-		panic(errors.New("invalid value"))
+		return reflect.Value{}, &UnsupportedTypeError{Kind: reflect.Invalid, Path: c.path.String()}
+	}
+	return c.copyPlan(ov, planFor(ov.Type()))
+}
+
+// copyPlan deep copies ov, following the plan p of its type.
+// We intentionally specify all supported types in the plan, so we return an error for all unsupported.
+func (c *copier) copyPlan(ov reflect.Value, p *plan) (reflect.Value, error) {
+	if c.opts.transform != nil {
+		if oc, handled, err := c.transform(ov); handled || err != nil {
+			return oc, err
+		}
+		// the values a primitive struct or array holds are reached one by one
+		switch {
+		case p.primitive && p.strategy == structStrategy:
+			return c.copyStruct(ov, p)
+		case p.primitive && p.strategy == arrayStrategy:
+			return c.copyArray(ov, p)
+		}
+	}
+	if p.primitive {
+		return ov, nil
+	}
+	if p.sensitive && c.opts.redact {
+		return c.redact(ov), nil
+	}
+	if p.lock {
+		return c.copyLock(ov)
+	}
+	if err := c.tick(); err != nil {
+		return reflect.Value{}, err
+	}
+	if c.opts.limits != (Limits{}) {
+		err := c.enter()
+		defer c.leave()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	if p.locker != noLocker && c.opts.lockSource {
+		if unlock := lock(ov, p.locker); unlock != nil {
+			defer unlock()
+		}
+	}
+	if p.copier != nil {
+		return c.copyCustom(ov, p.copier)
+	}
+	if p.method.kind != noMethod && !c.opts.ignoreMethods {
+		return c.copyWithMethod(ov, p.method)
+	}
+	switch p.strategy {
+	case assignStrategy:
+		return ov, nil
+	case structStrategy:
+		return c.copyStruct(ov, p)
+	case pointerStrategy:
+		return c.copyPointer(ov, p)
+	case sliceStrategy:
+		return c.copySlice(ov, p)
+	case mapStrategy:
+		return c.copyMap(ov, p)
+	case interfaceStrategy:
+		return c.copyInterface(ov)
+	case arrayStrategy:
+		return c.copyArray(ov, p)
+	case chanStrategy:
+		return c.copyChanMode(ov, p)
+	case funcStrategy:
+		return c.copyFunc(ov)
+	case syncMapStrategy:
+		return c.copySyncMap(ov)
+	}
+	return reflect.Value{}, &UnsupportedTypeError{Type: ov.Type(), Kind: ov.Kind(), Path: c.path.String()}
+}
+
+func (c *copier) copyInterface(ov reflect.Value) (reflect.Value, error) {
+	if ov.IsNil() {
+		return ov, nil
+	}
+	ec, err := c.copyr(ov.Elem())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	oc := reflect.New(ov.Type())
+	oc.Elem().Set(ec)
+	return oc.Elem(), nil
+}
+
+func (c *copier) copyPointer(ov reflect.Value, p *plan) (reflect.Value, error) {
+	if ov.IsNil() {
+		return ov, nil
+	}
+	key := visit{ptr: ov.Pointer(), typ: p.typ}
+	if oc, ok := c.seen(key); ok {
+		return oc, nil
+	}
+	if r, i := c.element(ov, p); r != nil {
+		return c.copyElement(r, i, ov, key, p)
+	}
+	if err := c.alloc(p.elem.typ.Size()); err != nil {
+		return reflect.Value{}, err
+	}
+	// we register the copy before copying the element, so cycles end up here
+	oc, ok := c.register(key, reflect.New(p.elem.typ))
+	if !ok {
+		return oc, nil
+	}
+	ec, err := c.copyPlan(ov.Elem(), p.elem)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	oc.Elem().Set(ec)
+	return oc, nil
+}
+
+// copyElement returns the pointer ov to the element i of the region r as a pointer to the copy of the element,
+// copying it unless it was already.
+func (c *copier) copyElement(r *region, i int, ov reflect.Value, key visit, p *plan) (reflect.Value, error) {
+	oc := r.copy.Index(i).Addr()
+	if oc.Type() != p.typ {
+		oc = oc.Convert(p.typ)
+	}
+	oc, ok := c.register(key, oc)
+	if !ok {
+		return oc, nil
+	}
+	if !r.track {
+		if c.shards != nil {
+			c.arraysMu.Lock()
+			defer c.arraysMu.Unlock()
+		}
+		oc.Elem().Set(ov.Elem())
+		return oc, nil
+	}
+	if from, to := c.claim(r, i, i+1); from == to {
+		return oc, nil
+	}
+	ec, err := c.copyPlan(ov.Elem(), p.elem)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	oc.Elem().Set(ec)
+	return oc, nil
+}
+
+func (c *copier) copyStruct(ov reflect.Value, p *plan) (reflect.Value, error) {
+	oc := reflect.New(p.typ).Elem()
+	if err := c.copyFields(oc, ov, p, false); err != nil {
+		return reflect.Value{}, err
+	}
+	return oc, nil
+}
+
+// copyFields copies the fields of the struct ov into the addressable struct oc, following the plan p.
+// If into is true, oc holds an older value, whose references are reused where possible,
+// and whose fields that are not copied are cleared.
+func (c *copier) copyFields(oc, ov reflect.Value, p *plan, into bool) error {
+	for i := range p.fields {
+		f := &p.fields[i]
+		fv, dst := ov.Field(f.index), oc.Field(f.index)
+		if f.direct && c.opts.transform == nil {
+			dst.Set(fv)
+			continue
+		}
+		mode := c.fieldMode(f, fv)
+		if mode == tagSkip {
+			if into {
+				if !f.exported {
+					dst = exposed(dst)
+				}
+				dst.SetZero()
+			}
+			continue
+		}
+		// runtime does not allow setting unexported fields, so we go around it
+		if !f.exported {
+			if !ov.CanAddr() {
+				ov = addressable(ov)
+				fv = ov.Field(f.index)
+			}
+			fv, dst = exposed(fv), exposed(dst)
+		}
+		c.path.push(PathStep{Kind: FieldStep, Name: f.name})
+		fc, tagged, err := c.copyTagged(fv, f.plan, mode)
+		switch {
+		case tagged:
+		case into:
+			err = c.copyInto(dst, fv, f.plan)
+		default:
+			fc, err = c.copyPlan(fv, f.plan)
+		}
+		c.path.pop()
+		if err != nil {
+			return err
+		}
+		if fc.IsValid() {
+			dst.Set(fc)
+		}
+	}
+	return nil
+}
+
+// fieldMode returns the way the field f with the value fv is copied, following its tag and the options.
+func (c *copier) fieldMode(f *fieldPlan, fv reflect.Value) tagMode {
+	mode := f.mode
+	if mode == tagRedact && !c.opts.redact {
+		mode = tagNone
+	}
+	if mode == tagNone && !f.exported {
+		switch c.opts.unexported {
+		case UnexportedZero:
+			mode = tagSkip
+		case UnexportedShallow:
+			mode = tagShallow
+		}
 	}
-	if t := ov.Type(); t.PkgPath() == "time" && t.Name() == "Time" {
-		return copyTime(ov)
+	// an unsupported value that is zero is left zero, instead of failing the copy
+	if f.plan.strategy == unsupportedStrategy && fv.IsZero() {
+		mode = tagSkip
+	}
+	return mode
+}
+
+// copyTagged copies the field value fv as the redact, shallow and deep modes say, and reports whether mode is one of them.
+// Every way of copying a struct copies its fields with it, and copies the other ones as it needs.
+func (c *copier) copyTagged(fv reflect.Value, p *plan, mode tagMode) (reflect.Value, bool, error) {
+	switch {
+	case mode == tagRedact:
+		return c.redact(fv), true, nil
+	case mode == tagShallow && !p.lock:
+		// a lock is never assigned, so it cannot be copied while locked
+		return fv, true, nil
+	case mode == tagDeep:
+		fc, err := c.copyForced(fv, p)
+		return fc, true, err
 	}
+	return reflect.Value{}, false, nil
+}
+
+// copyForced deep copies ov even if its kind is normally shared, as asked by the deep tag.
+func (c *copier) copyForced(ov reflect.Value, p *plan) (reflect.Value, error) {
 	switch ov.Kind() {
-	case reflect.Struct:
-		return copyStruct(ov)
-	case reflect.Ptr:
-		return copyPointer(ov)
-	case reflect.Slice:
-		return copySlice(ov)
-	case reflect.Map:
-		return copyMap(ov)
-	case reflect.Interface:
-		return copyInterface(ov)
-	case reflect.Array:
-		return copyArray(ov)
-	case reflect.Int, reflect.String, reflect.Int64, reflect.Float64, reflect.Bool, reflect.Uint, reflect.Uint64,
-		reflect.Func, reflect.Chan, reflect.Float32,
-		reflect.Int8, reflect.Int16, reflect.Int32,
-		reflect.Complex64, reflect.Complex128,
-		reflect.Uint8, reflect.Uint16, reflect.Uint32:
-		return ov
+	case reflect.Chan:
+		return c.copyChan(ov, p)
+	case reflect.Func:
+		if ov.IsNil() {
+			return ov, nil
+		}
+		// there is no way to copy the variables a closure captured
+		return reflect.Value{}, &ValueError{Type: ov.Type(), Path: c.path.String(), Reason: "a function cannot be deep copied"}
 	}
-	panic(fmt.Sprintf("unsupported type: %s", ov.Kind()))
+	return c.copyPlan(ov, p)
 }
 
-func copyTime(ov reflect.Value) reflect.Value {
-	return ov
+// copyChanMode copies the channel ov as WithChan says.
+func (c *copier) copyChanMode(ov reflect.Value, p *plan) (reflect.Value, error) {
+	switch c.opts.chans {
+	case ChanNil:
+		return reflect.Zero(ov.Type()), nil
+	case ChanEmpty:
+		if ov.IsNil() {
+			return ov, nil
+		}
+		key := visit{ptr: ov.Pointer(), typ: ov.Type()}
+		if oc, ok := c.seen(key); ok {
+			return oc, nil
+		}
+		if err := c.alloc(uintptr(ov.Cap()) * p.elem.typ.Size()); err != nil {
+			return reflect.Value{}, err
+		}
+		oc, _ := c.register(key, reflect.MakeChan(reflect.ChanOf(reflect.BothDir, ov.Type().Elem()), ov.Cap()).Convert(ov.Type()))
+		return oc, nil
+	case ChanFill:
+		return c.copyChan(ov, p)
+	}
+	return ov, nil
 }
 
-func copyInterface(ov reflect.Value) reflect.Value {
+// copyFunc copies the function ov as WithFunc says.
+func (c *copier) copyFunc(ov reflect.Value) (reflect.Value, error) {
 	if ov.IsNil() {
-		return ov
+		return ov, nil
 	}
-	oc := reflect.New(ov.Type())
-	oc.Elem().Set(copyr(ov.Elem()))
-	return oc.Elem()
+	switch c.opts.funcs {
+	case FuncNil:
+		return reflect.Zero(ov.Type()), nil
+	case FuncReject:
+		return reflect.Value{}, &ValueError{Type: ov.Type(), Path: c.path.String(), Reason: "functions are not allowed"}
+	case FuncStrict:
+		if name, ok := closure(ov); ok {
+			return reflect.Value{}, &ValueError{Type: ov.Type(), Path: c.path.String(), Reason: "closure " + name + " is not allowed"}
+		}
+	}
+	return ov, nil
+}
+
+// closureName matches the names the compiler gives to function literals, like main.run.func1.2,
+// and to method values, like main.(*T).Close-fm.
+var closureName = regexp.MustCompile(`\.func\d+(\.\d+)*$|-fm$`)
+
+// closure reports whether the function fn is a closure, together with its name.
+func closure(fn reflect.Value) (string, bool) {
+	f := runtime.FuncForPC(fn.Pointer())
+	if f == nil {
+		return "", false
+	}
+	return f.Name(), closureName.MatchString(f.Name())
 }
 
-func copyPointer(ov reflect.Value) reflect.Value {
+// copyChan returns a new channel with the same type and capacity as ov, holding copies of the elements buffered in ov.
+// The buffered elements are received from ov and sent back in the same order,
+// so ov must not be used concurrently and must not be closed.
+func (c *copier) copyChan(ov reflect.Value, p *plan) (reflect.Value, error) {
 	if ov.IsNil() {
-		return ov
+		return ov, nil
 	}
-	oc := reflect.New(ov.Type().Elem())
-	oc.Elem().Set(copyr(ov.Elem()))
-	return oc
+	key := visit{ptr: ov.Pointer(), typ: ov.Type()}
+	if oc, ok := c.seen(key); ok {
+		return oc, nil
+	}
+	if err := c.alloc(uintptr(ov.Cap()) * p.elem.typ.Size()); err != nil {
+		return reflect.Value{}, err
+	}
+	t := ov.Type()
+	bidi := t
+	if t.ChanDir() != reflect.BothDir {
+		bidi = reflect.ChanOf(reflect.BothDir, t.Elem())
+		if !ov.CanAddr() {
+			ov = addressable(ov)
+		}
+		// a directional channel is the same channel, the direction is only checked by the compiler
+		ov = reflect.NewAt(bidi, unsafe.Pointer(ov.UnsafeAddr())).Elem()
+	}
+	oc, elems, err := c.drainChan(key, ov)
+	if err != nil || elems == nil {
+		return oc, err
+	}
+	for i, e := range elems {
+		c.path.push(PathStep{Kind: IndexStep, Index: i})
+		ec, err := c.copyPlan(e, p.elem)
+		c.path.pop()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		oc.Send(ec)
+	}
+	return oc.Convert(t), nil
 }
 
-func copyStruct(ov reflect.Value) reflect.Value {
-	oc := reflect.New(ov.Type()).Elem()
-	for i := 0; i < ov.NumField(); i++ {
-		fv := ov.Field(i)
-		// we do not set unexported fields as runtime does not allow it
-		// also, runtime does not allow assigning a zero value, in case of pointers
-		if !fv.IsZero() && fv.CanInterface() {
-			oc.Field(i).Set(copyr(ov.Field(i)))
+// drainChan returns the elements buffered in the channel ov, which are sent back to it,
+// and registers a new channel with the same capacity as its copy, returned to be filled.
+// The elements are nil if another goroutine of a parallel copy registered a copy first, which is returned instead.
+func (c *copier) drainChan(key visit, ov reflect.Value) (reflect.Value, []reflect.Value, error) {
+	if c.slots != nil {
+		c.chans.Lock()
+		defer c.chans.Unlock()
+		if oc, ok := c.seen(key); ok {
+			return oc, nil, nil
 		}
 	}
+	elems := make([]reflect.Value, 0, ov.Len())
+	for n := ov.Len(); n > 0; n-- {
+		e, ok := ov.TryRecv()
+		if !ok {
+			break
+		}
+		elems = append(elems, e)
+	}
+	for _, e := range elems {
+		if !trySend(ov, e) {
+			return reflect.Value{}, nil, &ValueError{Type: key.typ, Path: c.path.String(), Reason: "the channel was closed or written to during the copy"}
+		}
+	}
+	oc := reflect.MakeChan(ov.Type(), ov.Cap())
+	// we register the copy before copying the elements, so cycles end up here
+	c.register(key, oc.Convert(key.typ))
+	return oc, elems, nil
+}
+
+// trySend sends e on the channel ch, without blocking, and reports whether it succeeded.
+func trySend(ch, e reflect.Value) (ok bool) {
+	defer func() {
+		// sending on a closed channel panics
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return ch.TrySend(e)
+}
+
+// addressable returns an addressable copy of ov.
+func addressable(ov reflect.Value) reflect.Value {
+	oc := reflect.New(ov.Type()).Elem()
+	oc.Set(ov)
 	return oc
 }
 
-func copySlice(ov reflect.Value) reflect.Value {
+// exposed returns the addressable value v, without the read only restriction of unexported fields.
+func exposed(v reflect.Value) reflect.Value {
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+func (c *copier) copySlice(ov reflect.Value, p *plan) (reflect.Value, error) {
 	if ov.IsNil() {
-		return ov
+		return ov, nil
 	}
-	oc := reflect.MakeSlice(ov.Type(), 0, ov.Cap())
-	for i := 0; i < ov.Len(); i++ {
-		oc = reflect.Append(oc, copyr(ov.Index(i)))
+	key := visit{ptr: ov.Pointer(), typ: p.typ, len: ov.Len(), cap: ov.Cap()}
+	if oc, ok := c.seen(key); ok {
+		return oc, nil
 	}
-	return oc
+	r, offset, err := c.region(ov, p)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if r != nil {
+		// slices sharing the backing array share its copy
+		oc := r.copy.Slice3(offset, offset+ov.Len(), offset+ov.Cap())
+		if oc.Type() != p.typ {
+			oc = oc.Convert(p.typ)
+		}
+		oc, ok := c.register(key, oc)
+		if !ok {
+			return oc, nil
+		}
+		if err := c.fillRegion(r, oc, ov, offset); err != nil {
+			return reflect.Value{}, err
+		}
+		return oc, nil
+	}
+	if err := c.alloc(uintptr(ov.Cap()) * p.elem.typ.Size()); err != nil {
+		return reflect.Value{}, err
+	}
+	oc, ok := c.register(key, reflect.MakeSlice(p.typ, ov.Len(), ov.Cap()))
+	if !ok {
+		return oc, nil
+	}
+	if p.elem.primitive && c.opts.transform == nil {
+		reflect.Copy(oc, ov)
+		return oc, nil
+	}
+	if c.parallel(ov.Len()) {
+		return oc, c.copyElemsParallel(oc, ov, p.elem, 0, ov.Len())
+	}
+	if err := c.copyElems(oc, ov, p.elem, 0, ov.Len()); err != nil {
+		return reflect.Value{}, err
+	}
+	return oc, nil
 }
 
-func copyArray(ov reflect.Value) reflect.Value {
-	array := reflect.New(ov.Type()).Elem()
-	slice := array.Slice3(0, 0, array.Len())
-	for i := 0; i < ov.Len(); i++ {
-		slice = reflect.Append(slice, copyr(ov.Index(i)))
+func (c *copier) copyArray(ov reflect.Value, p *plan) (reflect.Value, error) {
+	array := reflect.New(p.typ).Elem()
+	if err := c.copyElems(array, ov, p.elem, 0, ov.Len()); err != nil {
+		return reflect.Value{}, err
 	}
-	return array
+	return array, nil
 }
 
-func copyMap(ov reflect.Value) reflect.Value {
+// copyElems copies the elements of the slice or array ov from the index from up to to into oc,
+// which is at least as long. The elements follow the plan p.
+func (c *copier) copyElems(oc, ov reflect.Value, p *plan, from, to int) error {
+	for i := from; i < to; i++ {
+		c.path.push(PathStep{Kind: IndexStep, Index: i})
+		ec, err := c.copyPlan(ov.Index(i), p)
+		c.path.pop()
+		if err != nil {
+			return err
+		}
+		oc.Index(i).Set(ec)
+	}
+	return nil
+}
+
+func (c *copier) copyMap(ov reflect.Value, p *plan) (reflect.Value, error) {
 	if ov.IsNil() {
-		return ov
+		return ov, nil
+	}
+	key := visit{ptr: ov.Pointer(), typ: p.typ}
+	if oc, ok := c.seen(key); ok {
+		return oc, nil
+	}
+	if err := c.alloc(uintptr(ov.Len()) * (p.key.typ.Size() + p.elem.typ.Size())); err != nil {
+		return reflect.Value{}, err
+	}
+	oc, ok := c.register(key, reflect.MakeMapWithSize(p.typ, ov.Len()))
+	if !ok {
+		return oc, nil
+	}
+	if err := c.fillMap(oc, ov, p); err != nil {
+		return reflect.Value{}, err
+	}
+	return oc, nil
+}
+
+// copySyncMap deep copies the entries of the sync.Map ov into a new one.
+func (c *copier) copySyncMap(ov reflect.Value) (reflect.Value, error) {
+	if !ov.CanAddr() {
+		ov = addressable(ov)
+	}
+	oc := reflect.New(ov.Type())
+	m := oc.Interface().(*sync.Map)
+	var err error
+	ov.Addr().Interface().(*sync.Map).Range(func(k, v interface{}) bool {
+		c.path.push(PathStep{Kind: KeyStep, Key: reflect.ValueOf(k)})
+		defer c.path.pop()
+		var kc, vc reflect.Value
+		redacted := c.redacted
+		if kc, err = c.copyr(reflect.ValueOf(k)); err != nil {
+			return false
+		}
+		if c.redacted != redacted {
+			return true
+		}
+		if v == nil {
+			m.Store(kc.Interface(), nil)
+			return true
+		}
+		if vc, err = c.copyr(reflect.ValueOf(v)); err != nil {
+			return false
+		}
+		m.Store(kc.Interface(), vc.Interface())
+		return true
+	})
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return oc.Elem(), nil
+}
+
+// fillMap sets copies of the entries of the map ov in the map oc, following the plan p.
+func (c *copier) fillMap(oc, ov reflect.Value, p *plan) error {
+	if c.parallel(ov.Len()) && !(p.key.primitive && p.elem.primitive) {
+		return c.fillMapParallel(oc, ov, p)
 	}
-	oc := reflect.MakeMapWithSize(ov.Type(), ov.Len())
 	iter := ov.MapRange()
 	for iter.Next() {
-		oc.SetMapIndex(copyr(iter.Key()), copyr(iter.Value()))
+		if err := c.tick(); err != nil {
+			return err
+		}
+		c.path.push(PathStep{Kind: KeyStep, Key: iter.Key()})
+		redacted := c.redacted
+		kc, err := c.copyPlan(iter.Key(), p.key)
+		if err != nil || c.redacted != redacted {
+			c.path.pop()
+			if err != nil {
+				return err
+			}
+			// the entry of a redacted key is left out
+			continue
+		}
+		vc, err := c.copyPlan(iter.Value(), p.elem)
+		c.path.pop()
+		if err != nil {
+			return err
+		}
+		oc.SetMapIndex(kc, vc)
 	}
-	return oc
+	return nil
 }
 
+// isPrimitive reports whether values of type ot hold no references, so copying them by assignment is a deep copy.
+// Channels and functions are references, even though they are shared by default.
 func isPrimitive(ot reflect.Type) bool {
 	switch ot.Kind() {
 	case reflect.Int, reflect.String, reflect.Int64, reflect.Float64, reflect.Bool, reflect.Uint, reflect.Uint64,
-		reflect.Func, reflect.Chan, reflect.Float32,
+		reflect.Float32,
 		reflect.Int8, reflect.Int16, reflect.Int32,
 		reflect.Complex64, reflect.Complex128,
 		reflect.Uint8, reflect.Uint16, reflect.Uint32:
 		return true
+	case reflect.Array:
+		return isPrimitive(ot.Elem())
 	case reflect.Struct:
 		for i := 0; i < ot.NumField(); i++ {
 			if !isPrimitive(ot.Field(i).Type) {
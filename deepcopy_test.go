@@ -1,9 +1,12 @@
 package deepcopy
 
 import (
+	"context"
 	"encoding/gob"
+	"errors"
 	"io"
 	"io/ioutil"
+	"reflect"
 	"testing"
 	"time"
 
@@ -122,6 +125,260 @@ func TestCopyEquality(t *testing.T) {
 	}
 }
 
+func TestCopyCycle(t *testing.T) {
+	type Node struct {
+		Value      int
+		Prev, Next *Node
+	}
+	a := &Node{Value: 1}
+	b := &Node{Value: 2, Prev: a}
+	a.Next = b
+	b.Next = a
+	vi, err := Copy(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := vi.(*Node)
+	if v == a || v.Next == b {
+		t.Fatal("copy points to original")
+	}
+	if v.Next.Prev != v || v.Next.Next != v {
+		t.Fatalf("cycle not reproduced: %+v", v)
+	}
+	if v.Value != 1 || v.Next.Value != 2 {
+		t.Fatalf("got values: %d, %d, expected 1, 2", v.Value, v.Next.Value)
+	}
+}
+
+func TestCopyShared(t *testing.T) {
+	type S struct {
+		P1, P2 *int
+		M1, M2 map[int]int
+		S1, S2 []int
+	}
+	i := 1
+	m := map[int]int{1: 1}
+	s := []int{1, 2}
+	u := S{P1: &i, P2: &i, M1: m, M2: m, S1: s, S2: s}
+	vi, err := Copy(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := vi.(S)
+	if diff := cmp.Diff(u, v); diff != "" {
+		t.Fatal(diff)
+	}
+	if v.P1 != v.P2 || v.P1 == u.P1 {
+		t.Fatal("pointers are not shared in the copy")
+	}
+	v.M1[2] = 2
+	if len(v.M2) != 2 || len(u.M1) != 1 {
+		t.Fatal("maps are not shared in the copy")
+	}
+	v.S1[0] = 3
+	if v.S2[0] != 3 || u.S1[0] != 1 {
+		t.Fatal("slices are not shared in the copy")
+	}
+}
+
+func TestCopySharedArray(t *testing.T) {
+	type item struct {
+		N *int
+	}
+	type S struct {
+		A, B, C []item
+		P       *item
+		Ints    []int
+		Sub     []int
+		I       *int
+	}
+	n := 1
+	items := []item{{N: &n}, {}, {}, {}}
+	ints := []int{1, 2, 3, 4}
+	u := S{A: items[:2], B: items, C: items[1:3], P: &items[2], Ints: ints, Sub: ints[1:3], I: &ints[3]}
+	for _, opts := range [][]Option{nil, {WithParallel(4, 1)}} {
+		vi, err := Copy(u, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := vi.(S)
+		if diff := cmp.Diff(u, v); diff != "" {
+			t.Fatal(diff)
+		}
+		if &v.A[0] != &v.B[0] || &v.C[0] != &v.B[1] || v.P != &v.B[2] || cap(v.B) != 4 || &v.B[0] == &u.B[0] {
+			t.Fatal("expected the slices and the pointer sharing an array to share its copy")
+		}
+		if v.A[0].N == u.A[0].N || *v.A[0].N != 1 {
+			t.Fatal("expected the elements to be deep copied")
+		}
+		if &v.Sub[0] != &v.Ints[1] || v.I != &v.Ints[3] || &v.Ints[0] == &u.Ints[0] {
+			t.Fatal("expected the slices and the pointer sharing an array of ints to share its copy")
+		}
+		if refs := Shared(u, v); len(refs) != 0 {
+			t.Fatalf("got shared: %v, expected the copy to be independent", refs)
+		}
+	}
+
+	// the array is copied as far as the capacity of the first slice on it,
+	// so a pointer or a slice reaching further, found before, is copied apart
+	type late struct {
+		P     *int
+		Short []int
+		Long  []int
+	}
+	ints = []int{1, 2, 3}
+	v := MustClone(late{P: &ints[0], Short: ints[:1:1], Long: ints})
+	if v.P == &v.Long[0] || &v.Short[0] == &v.Long[0] || *v.P != 1 || v.Long[2] != 3 {
+		t.Fatalf("got: %v, expected the pointer and the short slice to be copied apart", v)
+	}
+}
+
+func TestCopyNil(t *testing.T) {
+	v, err := Copy(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != nil {
+		t.Fatalf("got value: %v, expected nil", v)
+	}
+}
+
+func TestCopyUnsupported(t *testing.T) {
+	type Handler struct {
+		Name string
+		ptr  uintptr
+		Ptr  uintptr
+	}
+	type Config struct {
+		Handlers []Handler
+		Named    map[string]*Handler
+	}
+	type Request struct {
+		Config Config
+	}
+	tests := []struct {
+		v    interface{}
+		path string
+	}{
+		{uintptr(1), ""},
+		{Request{Config: Config{Handlers: []Handler{{}, {Ptr: 1}}}}, ".Config.Handlers[1].Ptr"},
+		{Request{Config: Config{Named: map[string]*Handler{"a": {Ptr: 1}}}}, `.Config.Named["a"].Ptr`},
+	}
+	for _, test := range tests {
+		_, err := Copy(test.v)
+		var ue *UnsupportedTypeError
+		if !errors.As(err, &ue) {
+			t.Fatalf("got error: %v, expected *UnsupportedTypeError", err)
+		}
+		if ue.Kind != reflect.Uintptr || ue.Type != reflect.TypeOf(uintptr(0)) {
+			t.Fatalf("got type: %v, kind: %v, expected uintptr", ue.Type, ue.Kind)
+		}
+		if ue.Path != test.path {
+			t.Fatalf("got path: %s, expected: %s", ue.Path, test.path)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	type T struct {
+		A int
+		B string
+		C []int
+		D map[int][]int
+		E *[]int
+	}
+	u := T{}
+	f := fuzz.New()
+	f.Fuzz(&u)
+	v, err := Clone(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(u, v); diff != "" {
+		t.Fatal(diff)
+	}
+	var r io.Reader = &R{Data: []byte("clone")}
+	rc := MustClone(r)
+	if rc == r || rc.(*R).Data[0] != 'c' {
+		t.Fatalf("got: %#v, expected a copy of %#v", rc, r)
+	}
+	var nr io.Reader
+	if v := MustClone(nr); v != nil {
+		t.Fatalf("got value: %v, expected nil", v)
+	}
+}
+
+func TestMustClonePanics(t *testing.T) {
+	defer func() {
+		if _, ok := recover().(*UnsupportedTypeError); !ok {
+			t.Fatal("expected panic with *UnsupportedTypeError")
+		}
+	}()
+	MustClone(uintptr(1))
+}
+
+func TestCopyUnexported(t *testing.T) {
+	type inner struct {
+		m map[string]int
+	}
+	type S struct {
+		Exported int
+		private  *inner
+		values   []int
+	}
+	u := S{Exported: 1, private: &inner{m: map[string]int{"a": 1}}, values: []int{1, 2}}
+
+	vi, err := Copy(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := vi.(S); v.Exported != 1 || v.private != nil || v.values != nil {
+		t.Fatalf("got: %+v, expected unexported fields to be zero", v)
+	}
+
+	vi, err = Copy(u, WithUnexported(UnexportedShallow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := vi.(S); v.private != u.private || &v.values[0] != &u.values[0] {
+		t.Fatalf("got: %+v, expected unexported fields to be shared", v)
+	}
+
+	// the interface makes the struct not addressable
+	vi, err = Copy(&struct{ V interface{} }{V: u}, WithUnexported(UnexportedDeep))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := vi.(*struct{ V interface{} }).V.(S)
+	if !reflect.DeepEqual(u, v) {
+		t.Fatalf("got: %+v, expected: %+v", v, u)
+	}
+	if v.private == u.private || &v.values[0] == &u.values[0] {
+		t.Fatalf("got: %+v, expected unexported fields to be copied", v)
+	}
+	v.private.m["b"] = 2
+	if len(u.private.m) != 1 {
+		t.Fatal("unexported map is shared with the original")
+	}
+}
+
+func TestCopyUnexportedUnsupported(t *testing.T) {
+	type Handler struct {
+		ptr uintptr
+	}
+	type Config struct {
+		Handlers []Handler
+	}
+	_, err := Copy(struct{ Config Config }{Config{Handlers: []Handler{{}, {}, {}, {ptr: 1}}}}, WithUnexported(UnexportedDeep))
+	var ue *UnsupportedTypeError
+	if !errors.As(err, &ue) {
+		t.Fatalf("got error: %v, expected *UnsupportedTypeError", err)
+	}
+	if ue.Path != ".Config.Handlers[3].ptr" {
+		t.Fatalf("got path: %s, expected: .Config.Handlers[3].ptr", ue.Path)
+	}
+}
+
 var v interface{}
 var vt T
 
@@ -156,7 +413,8 @@ func BenchmarkCopy(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			go func() {
 				if err := ge.Encode(u); err != nil {
-					b.Fatal(err)
+					// the decoder will report the error
+					w.CloseWithError(err)
 				}
 			}()
 			if err := gd.Decode(&vT); err != nil {
@@ -195,3 +453,144 @@ type T struct {
 	F *[]int
 	G A
 }
+
+func TestCopyChan(t *testing.T) {
+	type worker struct {
+		Jobs    chan []int
+		Results <-chan int
+		Again   chan []int
+	}
+	jobs := make(chan []int, 3)
+	jobs <- []int{1}
+	jobs <- []int{2}
+	results := make(chan int)
+	w := worker{Jobs: jobs, Results: results, Again: jobs}
+
+	v, err := Clone(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Jobs != jobs || v.Results != results {
+		t.Fatal("expected the channels to be shared by default")
+	}
+
+	v, err = Clone(w, WithChan(ChanNil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Jobs != nil || v.Results != nil {
+		t.Fatal("expected nil channels")
+	}
+
+	v, err = Clone(w, WithChan(ChanEmpty))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Jobs == jobs || cap(v.Jobs) != 3 || len(v.Jobs) != 0 || v.Results == nil || v.Results == results {
+		t.Fatal("expected new empty channels with the same capacity")
+	}
+	if v.Again != v.Jobs {
+		t.Fatal("expected the shared channel to stay shared")
+	}
+
+	v, err = Clone(w, WithChan(ChanFill))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Jobs == jobs || cap(v.Jobs) != 3 || v.Again != v.Jobs {
+		t.Fatal("expected a new channel with the same capacity")
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("got %d elements, expected the original channel to keep its 2 elements", len(jobs))
+	}
+	for i, want := range []int{1, 2} {
+		got, orig := <-v.Jobs, <-jobs
+		if got[0] != want || orig[0] != want {
+			t.Fatalf("got: %v and %v at %d, expected: %d", got, orig, i, want)
+		}
+		if &got[0] == &orig[0] {
+			t.Fatal("expected the buffered elements to be deep copied")
+		}
+	}
+}
+
+func plainFunc() int { return 1 }
+
+type counter struct{ n int }
+
+func (c *counter) Inc() int { c.n++; return c.n }
+
+func TestCopyFunc(t *testing.T) {
+	type hooks struct {
+		F func() int
+		G func() int `deepcopy:"shallow"`
+	}
+	n := 0
+	literal := func() int { n++; return n }
+	h := hooks{F: plainFunc, G: literal}
+
+	v, err := Clone(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.F == nil || v.G == nil {
+		t.Fatal("expected the functions to be shared by default")
+	}
+
+	v, err = Clone(h, WithFunc(FuncNil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.F != nil || v.G == nil {
+		t.Fatal("expected only the untagged function to be nil")
+	}
+
+	var verr *ValueError
+	if _, err := Clone(h, WithFunc(FuncReject)); !errors.As(err, &verr) || verr.Path != ".F" {
+		t.Fatalf("got error: %v, expected a *ValueError at .F", err)
+	}
+	if _, err := Clone(hooks{}, WithFunc(FuncReject)); err != nil {
+		t.Fatalf("got error: %v, expected nil functions to be allowed", err)
+	}
+
+	if _, err := Clone(h, WithFunc(FuncStrict)); err != nil {
+		t.Fatalf("got error: %v, expected a package level function to be allowed", err)
+	}
+	for _, f := range []func() int{literal, new(counter).Inc} {
+		if _, err := Clone(hooks{F: f}, WithFunc(FuncStrict)); !errors.As(err, &verr) || verr.Path != ".F" {
+			t.Fatalf("got error: %v, expected a *ValueError at .F", err)
+		}
+	}
+}
+
+// cancelling cancels a context when it is copied.
+type cancelling struct {
+	cancel context.CancelFunc
+}
+
+func (c cancelling) DeepCopy() cancelling {
+	c.cancel()
+	return c
+}
+
+func TestCopyContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v := map[string][]cancelling{"a": make([]cancelling, 1000)}
+	for i := range v["a"] {
+		v["a"][i].cancel = cancel
+	}
+	if _, err := CopyContext(ctx, v); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error: %v, expected the copy to stop once the context is canceled", err)
+	}
+	if _, err := CopyContext(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error: %v, expected a canceled context to stop the copy before it starts", err)
+	}
+	vi, err := CopyContext(context.Background(), map[int]int{1: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := vi.(map[int]int); m[1] != 2 {
+		t.Fatalf("got: %v, expected a copy", m)
+	}
+}
@@ -0,0 +1,431 @@
+package deepcopy
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ChangeKind says how a value changed.
+type ChangeKind int
+
+const (
+	// Modified is a value that changed.
+	Modified ChangeKind = iota
+	// Added is an element appended to a slice or an entry added to a map.
+	Added
+	// Removed is an element cut from the end of a slice or an entry removed from a map.
+	Removed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Modified:
+		return "modified"
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change is a difference between two values, found by Diff.
+type Change struct {
+	// Path leads to the value that changed.
+	Path Path
+	Kind ChangeKind
+	// Old is a copy of the old value, nil for an added value.
+	Old interface{}
+	// New is a copy of the new value, nil for a removed value.
+	New interface{}
+}
+
+// Diff returns the changes that turn a into b, which must have the same type.
+// It walks both values the way Copy does: unexported fields and fields tagged with deepcopy:"-" are ignored,
+// and so are locks. Pointers and interfaces are followed, and cycles are walked once.
+// Values of types with a registered copier or a copy method, like time.Time, are compared as a whole,
+// with their Equal method if they have one, and with reflect.DeepEqual otherwise.
+// Channels and functions are compared by identity.
+// Slices are compared element by element, elements past the end of the shorter one are added or removed.
+// Map entries are reported in the order of their formatted keys.
+// The old and new values of the changes are copies, made with Copy.
+func Diff(a, b interface{}) ([]Change, error) {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !av.IsValid() || !bv.IsValid() {
+		if av.IsValid() || bv.IsValid() {
+			return []Change{{Kind: Modified, Old: a, New: b}}, nil
+		}
+		return nil, nil
+	}
+	if av.Type() != bv.Type() {
+		return nil, fmt.Errorf("deepcopy: cannot diff %s and %s", av.Type(), bv.Type())
+	}
+	d := differ{copier: newCopier(nil), walked: make(map[walk]bool)}
+	if err := d.diff(av, bv); err != nil {
+		return nil, err
+	}
+	return d.changes, nil
+}
+
+// differ holds the state of one Diff.
+type differ struct {
+	// copier copies the changed values and keeps the path.
+	*copier
+	changes []Change
+	// walked holds the pairs of references already walked.
+	walked map[walk]bool
+}
+
+// walk identifies a pair of references walked by Diff.
+type walk struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+func (d *differ) diff(a, b reflect.Value) error {
+	t := a.Type()
+	p := planFor(t)
+	if p.lock {
+		return nil
+	}
+	if p.copier != nil || p.method.kind != noMethod {
+		if !equal(a, b) {
+			return d.change(Modified, a, b)
+		}
+		return nil
+	}
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				return d.change(Modified, a, b)
+			}
+			return nil
+		}
+		if d.walk(a, b) {
+			return nil
+		}
+		return d.diff(a.Elem(), b.Elem())
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() || a.Elem().Type() != b.Elem().Type() {
+			if !a.IsNil() || !b.IsNil() {
+				return d.change(Modified, a, b)
+			}
+			return nil
+		}
+		return d.diff(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := range p.fields {
+			f := &p.fields[i]
+			if !f.exported || f.mode == tagSkip {
+				continue
+			}
+			d.path.push(PathStep{Kind: FieldStep, Name: f.name})
+			err := d.diff(a.Field(f.index), b.Field(f.index))
+			d.path.pop()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				return d.change(Modified, a, b)
+			}
+			return nil
+		}
+		if a.Len() == b.Len() && d.walk(a, b) {
+			return nil
+		}
+		return d.diffElems(a, b)
+	case reflect.Array:
+		return d.diffElems(a, b)
+	case reflect.Map:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				return d.change(Modified, a, b)
+			}
+			return nil
+		}
+		if d.walk(a, b) {
+			return nil
+		}
+		return d.diffMaps(a, b)
+	case reflect.Chan, reflect.Func:
+		if a.Pointer() != b.Pointer() {
+			return d.change(Modified, a, b)
+		}
+		return nil
+	}
+	if !a.Equal(b) {
+		return d.change(Modified, a, b)
+	}
+	return nil
+}
+
+// walk reports whether the references a and b were already walked, and marks them as walked.
+func (d *differ) walk(a, b reflect.Value) bool {
+	key := walk{a: a.Pointer(), b: b.Pointer(), typ: a.Type()}
+	if d.walked[key] {
+		return true
+	}
+	d.walked[key] = true
+	return false
+}
+
+// diffElems diffs the elements of the slices or arrays a and b.
+func (d *differ) diffElems(a, b reflect.Value) error {
+	n := min(a.Len(), b.Len())
+	for i := 0; i < max(a.Len(), b.Len()); i++ {
+		d.path.push(PathStep{Kind: IndexStep, Index: i})
+		var err error
+		switch {
+		case i >= n && i < b.Len():
+			err = d.change(Added, reflect.Value{}, b.Index(i))
+		case i >= n:
+			err = d.change(Removed, a.Index(i), reflect.Value{})
+		default:
+			err = d.diff(a.Index(i), b.Index(i))
+		}
+		d.path.pop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffMaps diffs the entries of the maps a and b.
+func (d *differ) diffMaps(a, b reflect.Value) error {
+	keys := a.MapKeys()
+	for _, k := range b.MapKeys() {
+		if !a.MapIndex(k).IsValid() {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return formatKey(keys[i]) < formatKey(keys[j])
+	})
+	for _, k := range keys {
+		av, bv := a.MapIndex(k), b.MapIndex(k)
+		d.path.push(PathStep{Kind: KeyStep, Key: k})
+		var err error
+		switch {
+		case !av.IsValid():
+			err = d.change(Added, av, bv)
+		case !bv.IsValid():
+			err = d.change(Removed, av, bv)
+		default:
+			err = d.diff(av, bv)
+		}
+		d.path.pop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// change records a change from a to b at the current path, with copies of a and b.
+func (d *differ) change(kind ChangeKind, a, b reflect.Value) error {
+	ch := Change{Path: append(Path(nil), d.path...), Kind: kind}
+	if a.IsValid() {
+		oc, err := d.copyr(a)
+		if err != nil {
+			return err
+		}
+		ch.Old = oc.Interface()
+	}
+	if b.IsValid() {
+		oc, err := d.copyr(b)
+		if err != nil {
+			return err
+		}
+		ch.New = oc.Interface()
+	}
+	d.changes = append(d.changes, ch)
+	return nil
+}
+
+// equal compares values copied as a whole, with their Equal method if they have one.
+func equal(a, b reflect.Value) bool {
+	if m := a.MethodByName("Equal"); m.IsValid() {
+		mt := m.Type()
+		if mt.NumIn() == 1 && mt.In(0) == a.Type() && mt.NumOut() == 1 && mt.Out(0).Kind() == reflect.Bool {
+			return m.Call([]reflect.Value{b})[0].Bool()
+		}
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// Patch applies the changes found by Diff to the value target points to, which has the type of the diffed values.
+// Applying the changes that turn a into b to a copy of a turns it into a copy of b.
+// The new values of the changes are set as they are, so they are shared by target and the changes,
+// and the values pointers point to are set in place, so the other pointers to them see the change.
+// The changes are applied in order, and Patch stops at the first one that cannot be applied, with a *PatchError.
+func Patch(target interface{}, changes []Change) error {
+	tv := reflect.ValueOf(target)
+	if tv.Kind() != reflect.Ptr || tv.IsNil() {
+		return fmt.Errorf("deepcopy: Patch target must be a non nil pointer, not %T", target)
+	}
+	for _, ch := range changes {
+		if err := patch(tv.Elem(), ch.Path, ch); err != nil {
+			return &PatchError{Path: ch.Path.String(), Reason: err.Error()}
+		}
+	}
+	return nil
+}
+
+// patch applies ch to the settable value v, which path leads from.
+func patch(v reflect.Value, path Path, ch Change) error {
+	for v.Kind() == reflect.Ptr && (len(path) > 0 || holds(v, ch)) {
+		if v.IsNil() {
+			return errors.New("nil pointer on the way")
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Interface && (len(path) > 0 || holds(v, ch)) {
+		if v.IsNil() {
+			return errors.New("nil interface on the way")
+		}
+		// the value in an interface cannot be set, so it is patched in a copy
+		ev := reflect.New(v.Elem().Type()).Elem()
+		ev.Set(v.Elem())
+		if err := patch(ev, path, ch); err != nil {
+			return err
+		}
+		v.Set(ev)
+		return nil
+	}
+	if len(path) == 0 {
+		if ch.Kind != Modified {
+			return fmt.Errorf("%s change without a slice index or a map key", ch.Kind)
+		}
+		nv, err := newValue(ch.New, v.Type())
+		if err != nil {
+			return err
+		}
+		v.Set(nv)
+		return nil
+	}
+	s, rest := path[0], path[1:]
+	switch s.Kind {
+	case FieldStep:
+		if v.Kind() != reflect.Struct {
+			return fmt.Errorf("field %s of %s", s.Name, v.Type())
+		}
+		f, ok := v.Type().FieldByName(s.Name)
+		if !ok || !f.IsExported() {
+			return fmt.Errorf("no exported field %s in %s", s.Name, v.Type())
+		}
+		return patch(v.FieldByIndex(f.Index), rest, ch)
+	case IndexStep:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return fmt.Errorf("index %d of %s", s.Index, v.Type())
+		}
+		if len(rest) == 0 && v.Kind() == reflect.Slice && ch.Kind != Modified {
+			return patchSlice(v, s.Index, ch)
+		}
+		if s.Index >= v.Len() {
+			return fmt.Errorf("index %d out of range", s.Index)
+		}
+		return patch(v.Index(s.Index), rest, ch)
+	case KeyStep:
+		if v.Kind() != reflect.Map {
+			return fmt.Errorf("key of %s", v.Type())
+		}
+		key, err := newValue(s.Key.Interface(), v.Type().Key())
+		if err != nil {
+			return err
+		}
+		if len(rest) == 0 && ch.Kind != Modified {
+			return patchMap(v, key, ch)
+		}
+		ev := v.MapIndex(key)
+		if !ev.IsValid() {
+			return fmt.Errorf("missing key %s", formatKey(key))
+		}
+		// a map entry cannot be set, so it is patched in a copy
+		cv := reflect.New(ev.Type()).Elem()
+		cv.Set(ev)
+		if err := patch(cv, rest, ch); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, cv)
+		return nil
+	}
+	return fmt.Errorf("unknown step kind %d", s.Kind)
+}
+
+// holds reports whether the non nil pointer or interface v holds the value a change at the end of its path is of.
+// Diff follows pointers and interfaces without a step, so such a change is of the first value with the type of its old value.
+func holds(v reflect.Value, ch Change) bool {
+	x := ch.Old
+	if x == nil {
+		x = ch.New
+	}
+	if x == nil || v.IsNil() {
+		return false
+	}
+	if v.Kind() == reflect.Interface {
+		return v.Elem().Type() != reflect.TypeOf(x)
+	}
+	return v.Type() != reflect.TypeOf(x)
+}
+
+// patchSlice adds or removes the element at index i of the slice v.
+func patchSlice(v reflect.Value, i int, ch Change) error {
+	if ch.Kind == Removed {
+		if i < v.Len() {
+			v.Set(v.Slice(0, i))
+		}
+		return nil
+	}
+	if i > v.Len() {
+		return fmt.Errorf("index %d past the end", i)
+	}
+	ev, err := newValue(ch.New, v.Type().Elem())
+	if err != nil {
+		return err
+	}
+	if i == v.Len() {
+		v.Set(reflect.Append(v, ev))
+		return nil
+	}
+	v.Index(i).Set(ev)
+	return nil
+}
+
+// patchMap adds or removes the entry with key of the map v.
+func patchMap(v, key reflect.Value, ch Change) error {
+	if ch.Kind == Removed {
+		if !v.IsNil() {
+			v.SetMapIndex(key, reflect.Value{})
+		}
+		return nil
+	}
+	ev, err := newValue(ch.New, v.Type().Elem())
+	if err != nil {
+		return err
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	v.SetMapIndex(key, ev)
+	return nil
+}
+
+// newValue returns x as a value of type t.
+func newValue(x interface{}, t reflect.Type) (reflect.Value, error) {
+	if x == nil {
+		return reflect.Zero(t), nil
+	}
+	v := reflect.ValueOf(x)
+	if !v.Type().AssignableTo(t) {
+		return reflect.Value{}, fmt.Errorf("%s is not assignable to %s", v.Type(), t)
+	}
+	return v, nil
+}
@@ -0,0 +1,132 @@
+package deepcopy
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type account struct {
+	Name    string
+	Balance float64
+	Tags    []string
+	Limits  map[string]int
+	Owner   *account
+	Opened  time.Time
+	Meta    interface{}
+	Cache   []int `deepcopy:"-"`
+	version int
+}
+
+func TestDiffPatch(t *testing.T) {
+	opened := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	live := &account{
+		Name:    "a",
+		Balance: 1,
+		Tags:    []string{"x", "y", "z"},
+		Limits:  map[string]int{"daily": 1, "monthly": 2},
+		Owner:   &account{Name: "owner"},
+		Opened:  opened,
+		Meta:    map[string]interface{}{"k": []int{1}},
+		version: 1,
+	}
+	live.Owner.Owner = live
+	before := MustClone(live)
+
+	live.Name = "b"
+	live.Tags = append(live.Tags[:1], "w")
+	live.Limits["daily"] = 3
+	delete(live.Limits, "monthly")
+	live.Limits["yearly"] = 4
+	live.Owner.Name = "new owner"
+	live.Opened = opened.In(time.FixedZone("X", 3600))
+	live.Meta.(map[string]interface{})["k"] = []int{1, 2}
+	live.Cache = []int{1}
+	live.version = 2
+
+	changes, err := Diff(before, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type change struct {
+		Path     string
+		Kind     ChangeKind
+		Old, New interface{}
+	}
+	var got []change
+	for _, ch := range changes {
+		got = append(got, change{ch.Path.String(), ch.Kind, ch.Old, ch.New})
+	}
+	expected := []change{
+		{".Name", Modified, "a", "b"},
+		{".Tags[1]", Modified, "y", "w"},
+		{".Tags[2]", Removed, "z", nil},
+		{`.Limits["daily"]`, Modified, 1, 3},
+		{`.Limits["monthly"]`, Removed, 2, nil},
+		{`.Limits["yearly"]`, Added, nil, 4},
+		{".Owner.Name", Modified, "owner", "new owner"},
+		{`.Meta["k"][1]`, Added, nil, 2},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Fatal(diff)
+	}
+
+	patched := MustClone(before)
+	if err := Patch(&patched, changes); err != nil {
+		t.Fatal(err)
+	}
+	expectedPatched := MustClone(live)
+	expectedPatched.Cache = nil
+	// the same instant in another location is not a change
+	expectedPatched.Opened = opened
+	if !reflect.DeepEqual(patched, expectedPatched) {
+		t.Fatalf("got: %+v, expected: %+v", patched, expectedPatched)
+	}
+	if changes, err := Diff(patched, live); err != nil || len(changes) != 0 {
+		t.Fatalf("got changes: %+v, error: %v, expected none", changes, err)
+	}
+
+	// the values pointers and interfaces hold are patched where they are, so the pointers sharing them see it
+	type leaves struct {
+		P, Q *int
+		I    interface{}
+		T    *time.Time
+	}
+	one, two, three := 1, 2, 3
+	old := leaves{P: &one, I: &two, T: &opened}
+	old.Q = old.P
+	changed := MustClone(old)
+	*changed.P, *changed.I.(*int), *changed.T = 4, 5, opened.Add(time.Hour)
+	for _, b := range []leaves{changed, {P: &three, Q: &three, I: 6}} {
+		changes, err := Diff(old, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		patched := MustClone(old)
+		if err := Patch(&patched, changes); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(patched, b) || patched.P != patched.Q {
+			t.Fatalf("got: %+v, expected: %+v, with the changes: %+v", patched, b, changes)
+		}
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	if _, err := Diff(1, "1"); err == nil {
+		t.Fatal("expected an error for values of different types")
+	}
+	changes, err := Diff(nil, 1)
+	if err != nil || len(changes) != 1 || changes[0].New != 1 {
+		t.Fatalf("got changes: %+v, error: %v, expected one change", changes, err)
+	}
+	var perr *PatchError
+	v := &account{}
+	err = Patch(&v, []Change{{Path: Path{{Kind: FieldStep, Name: "Owner"}, {Kind: FieldStep, Name: "Name"}}, New: "x"}})
+	if !errors.As(err, &perr) || perr.Path != ".Owner.Name" {
+		t.Fatalf("got error: %v, expected a *PatchError at .Owner.Name", err)
+	}
+}
@@ -0,0 +1,139 @@
+package deepcopy
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// UnsupportedTypeError is returned by Copy when it finds a value it does not know how to copy,
+// like an uintptr or an unsafe.Pointer.
+type UnsupportedTypeError struct {
+	// Type is the type of the value, it is nil for an invalid value.
+	Type reflect.Type
+	Kind reflect.Kind
+	// Path leads from the copied value to the offending one, e.g. .Config.Handlers[3].ptr.
+	// It is empty for the copied value itself.
+	Path string
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	msg := "deepcopy: unsupported type "
+	switch {
+	case e.Type == nil:
+		msg += e.Kind.String()
+	case e.Type.String() == e.Kind.String():
+		msg += e.Type.String()
+	default:
+		msg += fmt.Sprintf("%s (%s)", e.Type, e.Kind)
+	}
+	if e.Path != "" {
+		msg += " at " + e.Path
+	}
+	return msg
+}
+
+// CopierError is returned by Copy when a copier registered with RegisterCopier fails.
+type CopierError struct {
+	// Type is the type of the copied value.
+	Type reflect.Type
+	// Path leads from the copied value to the one the copier failed for.
+	Path string
+	Err  error
+}
+
+func (e *CopierError) Error() string {
+	msg := "deepcopy: copier for " + e.Type.String()
+	if e.Path != "" {
+		msg += " at " + e.Path
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *CopierError) Unwrap() error {
+	return e.Err
+}
+
+// TransformError is returned by Copy when a TransformFunc set by WithTransform fails.
+type TransformError struct {
+	// Type is the type of the transformed value.
+	Type reflect.Type
+	// Path leads from the copied value to the one the transform failed for.
+	Path string
+	Err  error
+}
+
+func (e *TransformError) Error() string {
+	msg := "deepcopy: transform of " + e.Type.String()
+	if e.Path != "" {
+		msg += " at " + e.Path
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *TransformError) Unwrap() error {
+	return e.Err
+}
+
+// PatchError is returned by Patch when a change cannot be applied.
+type PatchError struct {
+	// Path is the path of the change.
+	Path   string
+	Reason string
+}
+
+func (e *PatchError) Error() string {
+	return "deepcopy: cannot patch " + e.Path + ": " + e.Reason
+}
+
+// ConversionError is returned by CopyTo when a value cannot be converted to the type of its destination.
+type ConversionError struct {
+	From, To reflect.Type
+	// Path leads from the copied value to the one that cannot be converted.
+	Path   string
+	Reason string
+}
+
+func (e *ConversionError) Error() string {
+	msg := fmt.Sprintf("deepcopy: cannot convert %s to %s", e.From, e.To)
+	if e.Path != "" {
+		msg += " at " + e.Path
+	}
+	return msg + ": " + e.Reason
+}
+
+// errInvalidCopy is reported when a copier returns a value that cannot be assigned to the copied type.
+var errInvalidCopy = errors.New("copier returned a value of a wrong type")
+
+// ValueError is returned by Copy when it finds a value of a supported type that still cannot be copied.
+type ValueError struct {
+	Type reflect.Type
+	// Path leads from the copied value to the offending one.
+	Path   string
+	Reason string
+}
+
+func (e *ValueError) Error() string {
+	msg := "deepcopy: cannot copy " + e.Type.String()
+	if e.Path != "" {
+		msg += " at " + e.Path
+	}
+	return msg + ": " + e.Reason
+}
+
+// LimitError is returned by Copy when the copy crosses one of the limits set by WithLimits.
+type LimitError struct {
+	// Limit is the crossed limit: depth, nodes or bytes.
+	Limit string
+	Max   int
+	// Path leads from the copied value to the one that crossed the limit.
+	Path string
+}
+
+func (e *LimitError) Error() string {
+	msg := fmt.Sprintf("deepcopy: copy exceeds max %s %d", e.Limit, e.Max)
+	if e.Path != "" {
+		msg += " at " + e.Path
+	}
+	return msg
+}
@@ -1,9 +1,11 @@
 module github.com/gadumitrachioaiei/deepcopy
 
-go 1.13
+go 1.21
 
 require (
 	github.com/google/go-cmp v0.4.0
 	github.com/google/gofuzz v1.1.0
 	github.com/mitchellh/copystructure v1.0.0
 )
+
+require github.com/mitchellh/reflectwalk v1.0.0 // indirect
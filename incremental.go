@@ -0,0 +1,340 @@
+package deepcopy
+
+import "reflect"
+
+// CopyIncremental returns a deepcopy of cur, following the same rules as Copy,
+// that reuses the parts of prevCopy, a copy of prevSrc made with the same options, where cur has not changed.
+// Every part of cur is compared with the part of prevSrc found at the same path, and if they are structurally equal,
+// the matching part of prevCopy is returned instead of a new copy, so the copy shares memory with prevCopy.
+// This is only safe when neither copy is modified, as it is the case for snapshots.
+// Pointers, maps and slices are compared by what they hold, while the values kept shared with cur,
+// like channels, functions and the fields tagged shallow, must be the same as in prevSrc.
+// Maps are reused only when their keys hold no references, and parts of a cycle are never reused.
+// prevSrc must not have been modified since prevCopy was made, so cur cannot be the same value as prevSrc
+// modified in place. When the types of the three values differ, or WithTransform is used, cur is copied anew.
+// The copy is sequential, regardless of WithParallel.
+func CopyIncremental(prevSrc, prevCopy, cur interface{}, opts ...Option) (interface{}, error) {
+	if cur == nil {
+		return nil, nil
+	}
+	in := &incremental{
+		copier: newCopier(append(opts[:len(opts):len(opts)], WithParallel(0, 0))),
+		done:   make(map[visit]visit),
+	}
+	cv, pv, qv := reflect.ValueOf(cur), reflect.ValueOf(prevSrc), reflect.ValueOf(prevCopy)
+	var oc reflect.Value
+	var err error
+	if in.opts.transform != nil || !pv.IsValid() || !qv.IsValid() || pv.Type() != cv.Type() || qv.Type() != cv.Type() {
+		oc, err = in.copyr(cv)
+	} else {
+		oc, _, err = in.copy(cv, pv, qv, planFor(cv.Type()))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return oc.Interface(), nil
+}
+
+// incremental holds the state of one CopyIncremental.
+type incremental struct {
+	*copier
+	// done maps the references of cur whose copy is reused from prevCopy to the references of prevSrc they match.
+	done map[visit]visit
+}
+
+// copy deep copies cv, reusing qv, the previous copy of pv, if cv and pv are equal,
+// and reports whether it did.
+func (in *incremental) copy(cv, pv, qv reflect.Value, p *plan) (reflect.Value, bool, error) {
+	switch {
+	case p.primitive:
+		return cv, reflect.DeepEqual(cv.Interface(), pv.Interface()), nil
+	case p.lock:
+		// locks are reset in both copies, or the copy fails
+		oc, err := in.copyPlan(cv, p)
+		return oc, true, err
+	case p.copier != nil || p.method.kind != noMethod && !in.opts.ignoreMethods || p.sensitive && in.opts.redact:
+		return in.leaf(cv, pv, qv, p)
+	}
+	switch p.strategy {
+	case structStrategy, pointerStrategy, sliceStrategy, arrayStrategy, mapStrategy, interfaceStrategy:
+	default:
+		return in.leaf(cv, pv, qv, p)
+	}
+	// the values compared count against the limits and are locked as if they were copied, reused or not,
+	// so the ones copied anew are copied with the strategies of the copier rather than with copyPlan, which would do it again
+	if err := in.tick(); err != nil {
+		return reflect.Value{}, false, err
+	}
+	if in.opts.limits != (Limits{}) {
+		err := in.enter()
+		defer in.leave()
+		if err != nil {
+			return reflect.Value{}, false, err
+		}
+	}
+	if p.locker != noLocker && in.opts.lockSource {
+		if unlock := lock(cv, p.locker); unlock != nil {
+			defer unlock()
+		}
+	}
+	switch p.strategy {
+	case structStrategy:
+		return in.copyStruct(cv, pv, qv, p)
+	case pointerStrategy:
+		return in.copyPointer(cv, pv, qv, p)
+	case sliceStrategy:
+		return in.copySlice(cv, pv, qv, p)
+	case arrayStrategy:
+		oc := reflect.New(p.typ).Elem()
+		same, err := in.copyElems(oc, cv, pv, qv, p.elem)
+		if same {
+			return qv, true, err
+		}
+		return oc, false, err
+	case mapStrategy:
+		return in.copyMap(cv, pv, qv, p)
+	}
+	return in.copyInterface(cv, pv, qv, p)
+}
+
+// leaf copies cv as a whole, reusing qv if cv is identical to pv.
+func (in *incremental) leaf(cv, pv, qv reflect.Value, p *plan) (reflect.Value, bool, error) {
+	if identical(cv, pv) {
+		return qv, true, nil
+	}
+	oc, err := in.copyPlan(cv, p)
+	return oc, false, err
+}
+
+// identical reports whether a and b are deeply equal, and reference the same memory if they are shared kinds.
+func identical(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer()
+	case reflect.Slice:
+		return a.Pointer() == b.Pointer() && a.Len() == b.Len() && a.Cap() == b.Cap()
+	case reflect.Func:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		ka, _ := reference(a)
+		kb, _ := reference(b)
+		return ka == kb
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// reused returns the copy of the reference of cv identified by key, if it was already copied,
+// and reports whether it is the previous copy of pv, identified by pkey.
+func (in *incremental) reused(key, pkey visit) (reflect.Value, bool, bool) {
+	oc, ok := in.seen(key)
+	if !ok {
+		return oc, false, false
+	}
+	prev, done := in.done[key]
+	return oc, done && prev == pkey, true
+}
+
+// reuse records qv as the copy of the reference of cv identified by key, matching the reference of pv identified by pkey.
+func (in *incremental) reuse(key, pkey visit, qv reflect.Value) (reflect.Value, bool, error) {
+	in.register(key, qv)
+	in.done[key] = pkey
+	return qv, true, nil
+}
+
+func (in *incremental) copyStruct(cv, pv, qv reflect.Value, p *plan) (reflect.Value, bool, error) {
+	oc := reflect.New(p.typ).Elem()
+	same := true
+	for i := range p.fields {
+		f := &p.fields[i]
+		fv := cv.Field(f.index)
+		mode := in.fieldMode(f, fv)
+		if mode == tagSkip {
+			continue
+		}
+		// runtime does not allow setting unexported fields, so we go around it
+		if !f.exported {
+			if !cv.CanAddr() {
+				cv = addressable(cv)
+			}
+			if !pv.CanAddr() {
+				pv = addressable(pv)
+			}
+			if !qv.CanAddr() {
+				qv = addressable(qv)
+			}
+		}
+		fv, pf, qf, dst := cv.Field(f.index), pv.Field(f.index), qv.Field(f.index), oc.Field(f.index)
+		if !f.exported {
+			fv, pf, qf, dst = exposed(fv), exposed(pf), exposed(qf), exposed(dst)
+		}
+		in.path.push(PathStep{Kind: FieldStep, Name: f.name})
+		var fc reflect.Value
+		var fieldSame, tagged bool
+		var err error
+		if mode == tagDeep && reflect.DeepEqual(fv.Interface(), pf.Interface()) {
+			fc, fieldSame = qf, true
+		} else if fc, tagged, err = in.copyTagged(fv, f.plan, mode); tagged {
+			// a redacted value is the same as before if the original is, a shared one if it is the same memory
+			fieldSame = mode == tagRedact && reflect.DeepEqual(fv.Interface(), pf.Interface()) || mode == tagShallow && identical(fv, pf)
+		} else {
+			fc, fieldSame, err = in.copy(fv, pf, qf, f.plan)
+		}
+		in.path.pop()
+		if err != nil {
+			return reflect.Value{}, false, err
+		}
+		dst.Set(fc)
+		same = same && fieldSame
+	}
+	if same {
+		return qv, true, nil
+	}
+	return oc, false, nil
+}
+
+func (in *incremental) copyPointer(cv, pv, qv reflect.Value, p *plan) (reflect.Value, bool, error) {
+	if cv.IsNil() {
+		return cv, pv.IsNil(), nil
+	}
+	key, pkey := visit{ptr: cv.Pointer(), typ: p.typ}, visit{ptr: pv.Pointer(), typ: p.typ}
+	if oc, same, ok := in.reused(key, pkey); ok {
+		return oc, same, nil
+	}
+	if pv.IsNil() || qv.IsNil() {
+		oc, err := in.copier.copyPointer(cv, p)
+		return oc, false, err
+	}
+	if err := in.alloc(p.elem.typ.Size()); err != nil {
+		return reflect.Value{}, false, err
+	}
+	// we register the copy before copying the element, so cycles end up here, and are not reused
+	oc, _ := in.register(key, reflect.New(p.elem.typ))
+	ec, same, err := in.copy(cv.Elem(), pv.Elem(), qv.Elem(), p.elem)
+	if err != nil {
+		return reflect.Value{}, false, err
+	}
+	if same {
+		return in.reuse(key, pkey, qv)
+	}
+	oc.Elem().Set(ec)
+	return oc, false, nil
+}
+
+func (in *incremental) copySlice(cv, pv, qv reflect.Value, p *plan) (reflect.Value, bool, error) {
+	if cv.IsNil() {
+		return cv, pv.IsNil(), nil
+	}
+	key := visit{ptr: cv.Pointer(), typ: p.typ, len: cv.Len(), cap: cv.Cap()}
+	pkey := visit{ptr: pv.Pointer(), typ: p.typ, len: pv.Len(), cap: pv.Cap()}
+	if oc, same, ok := in.reused(key, pkey); ok {
+		return oc, same, nil
+	}
+	if pv.IsNil() || qv.IsNil() || qv.Len() != pv.Len() {
+		oc, err := in.copier.copySlice(cv, p)
+		return oc, false, err
+	}
+	if p.elem.primitive {
+		if cv.Len() == pv.Len() && reflect.DeepEqual(cv.Interface(), pv.Interface()) {
+			return in.reuse(key, pkey, qv)
+		}
+		oc, err := in.copier.copySlice(cv, p)
+		return oc, false, err
+	}
+	if err := in.alloc(uintptr(cv.Cap()) * p.elem.typ.Size()); err != nil {
+		return reflect.Value{}, false, err
+	}
+	oc, _ := in.register(key, reflect.MakeSlice(p.typ, cv.Len(), cv.Cap()))
+	same, err := in.copyElems(oc, cv, pv, qv, p.elem)
+	if err != nil {
+		return reflect.Value{}, false, err
+	}
+	if same {
+		return in.reuse(key, pkey, qv)
+	}
+	return oc, false, nil
+}
+
+// copyElems copies the elements of the slice or array cv into oc, and reports whether they are all reused.
+// The elements past the length of pv are copied anew.
+func (in *incremental) copyElems(oc, cv, pv, qv reflect.Value, p *plan) (bool, error) {
+	same := cv.Len() == pv.Len()
+	for i := 0; i < cv.Len(); i++ {
+		in.path.push(PathStep{Kind: IndexStep, Index: i})
+		var ec reflect.Value
+		var elemSame bool
+		var err error
+		if i < pv.Len() {
+			ec, elemSame, err = in.copy(cv.Index(i), pv.Index(i), qv.Index(i), p)
+		} else {
+			ec, err = in.copyPlan(cv.Index(i), p)
+		}
+		in.path.pop()
+		if err != nil {
+			return false, err
+		}
+		oc.Index(i).Set(ec)
+		same = same && elemSame
+	}
+	return same, nil
+}
+
+func (in *incremental) copyMap(cv, pv, qv reflect.Value, p *plan) (reflect.Value, bool, error) {
+	if cv.IsNil() {
+		return cv, pv.IsNil(), nil
+	}
+	key, pkey := visit{ptr: cv.Pointer(), typ: p.typ}, visit{ptr: pv.Pointer(), typ: p.typ}
+	if oc, same, ok := in.reused(key, pkey); ok {
+		return oc, same, nil
+	}
+	// keys holding references are copied, so they cannot be found in the previous copy
+	if pv.IsNil() || qv.IsNil() || qv.Len() != pv.Len() || !p.key.primitive {
+		oc, err := in.copier.copyMap(cv, p)
+		return oc, false, err
+	}
+	if err := in.alloc(uintptr(cv.Len()) * (p.key.typ.Size() + p.elem.typ.Size())); err != nil {
+		return reflect.Value{}, false, err
+	}
+	oc, _ := in.register(key, reflect.MakeMapWithSize(p.typ, cv.Len()))
+	same := cv.Len() == pv.Len()
+	iter := cv.MapRange()
+	for iter.Next() {
+		k, v := iter.Key(), iter.Value()
+		in.path.push(PathStep{Kind: KeyStep, Key: k})
+		var ec reflect.Value
+		var elemSame bool
+		var err error
+		if pe, qe := pv.MapIndex(k), qv.MapIndex(k); pe.IsValid() && qe.IsValid() {
+			ec, elemSame, err = in.copy(v, pe, qe, p.elem)
+		} else {
+			ec, err = in.copyPlan(v, p.elem)
+		}
+		in.path.pop()
+		if err != nil {
+			return reflect.Value{}, false, err
+		}
+		oc.SetMapIndex(k, ec)
+		same = same && elemSame
+	}
+	if same {
+		return in.reuse(key, pkey, qv)
+	}
+	return oc, false, nil
+}
+
+func (in *incremental) copyInterface(cv, pv, qv reflect.Value, p *plan) (reflect.Value, bool, error) {
+	if cv.IsNil() {
+		return cv, pv.IsNil(), nil
+	}
+	if pv.IsNil() || qv.IsNil() || cv.Elem().Type() != pv.Elem().Type() || qv.Elem().Type() != pv.Elem().Type() {
+		oc, err := in.copier.copyInterface(cv)
+		return oc, false, err
+	}
+	ec, same, err := in.copy(cv.Elem(), pv.Elem(), qv.Elem(), planFor(cv.Elem().Type()))
+	if err != nil || same {
+		return qv, same, err
+	}
+	oc := reflect.New(p.typ).Elem()
+	oc.Set(ec)
+	return oc, false, nil
+}
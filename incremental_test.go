@@ -0,0 +1,135 @@
+package deepcopy
+
+import (
+	"reflect"
+	"testing"
+)
+
+type snapshot struct {
+	Version int
+	Users   map[string]*user
+	Groups  []*group
+	Config  *layer
+	Events  chan int
+	Self    *snapshot
+	Note    interface{}
+}
+
+type user struct {
+	Name  string
+	Roles []string
+}
+
+type group struct {
+	Name    string
+	Members []*user
+}
+
+func newSnapshot() *snapshot {
+	alice, bob := &user{Name: "alice", Roles: []string{"admin"}}, &user{Name: "bob"}
+	s := &snapshot{
+		Version: 1,
+		Users:   map[string]*user{"alice": alice, "bob": bob},
+		Groups:  []*group{{Name: "admins", Members: []*user{alice}}, {Name: "all", Members: []*user{alice, bob}}},
+		Config:  &layer{Name: "config", Hosts: []string{"a"}},
+		Events:  make(chan int),
+		Note:    []int{1},
+	}
+	return s
+}
+
+func TestCopyIncremental(t *testing.T) {
+	prevSrc := newSnapshot()
+	prevCopy := MustClone(prevSrc)
+
+	cur := MustClone(prevSrc)
+	cur.Version = 2
+	cur.Users["bob"].Roles = []string{"dev"}
+	v, err := CopyIncremental(prevSrc, prevCopy, cur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := v.(*snapshot)
+	if !reflect.DeepEqual(next, cur) {
+		t.Fatalf("got: %+v, expected: %+v", next, cur)
+	}
+	if refs := Shared(next, cur); len(refs) != 1 || refs[0].Kind != reflect.Chan {
+		t.Fatalf("got shared with the current value: %v, expected only the channel", refs)
+	}
+	if next.Config != prevCopy.Config || next.Users["alice"] != prevCopy.Users["alice"] || next.Groups[0] != prevCopy.Groups[0] {
+		t.Fatal("expected the unchanged parts of the previous copy to be reused")
+	}
+	if next == prevCopy || next.Users["bob"] == prevCopy.Users["bob"] || next.Groups[1] == prevCopy.Groups[1] {
+		t.Fatal("expected the changed parts to be copied anew")
+	}
+	if next.Groups[1].Members[0] != next.Users["alice"] || next.Groups[1].Members[1] != next.Users["bob"] {
+		t.Fatal("expected the shared pointers to stay shared")
+	}
+
+	// a new channel is not the same value, so the snapshot is not reused
+	cur = MustClone(prevSrc)
+	cur.Events = make(chan int)
+	if v, _ := CopyIncremental(prevSrc, prevCopy, cur); v.(*snapshot) == prevCopy || v.(*snapshot).Config != prevCopy.Config {
+		t.Fatal("expected only the channel and what holds it to be copied anew")
+	}
+	cur.Events = prevSrc.Events
+	if v, _ := CopyIncremental(prevSrc, prevCopy, cur); v.(*snapshot) != prevCopy {
+		t.Fatal("expected an unchanged value to reuse the previous copy")
+	}
+}
+
+func TestCopyIncrementalCycle(t *testing.T) {
+	prevSrc := newSnapshot()
+	prevSrc.Self = prevSrc
+	prevCopy := MustClone(prevSrc)
+	cur := MustClone(prevSrc)
+	v, err := CopyIncremental(prevSrc, prevCopy, cur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := v.(*snapshot)
+	if next == prevCopy || next.Self != next || next.Config != prevCopy.Config {
+		t.Fatal("expected the cycle to be copied anew, and the rest to be reused")
+	}
+
+	// values of another type are copied anew
+	v, err = CopyIncremental(1, 2, cur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := v.(*snapshot); next.Config == cur.Config || next.Config == prevCopy.Config || !reflect.DeepEqual(next.Config, cur.Config) {
+		t.Fatal("expected a new copy")
+	}
+}
+
+// lockCounter counts how many times it is locked.
+type lockCounter struct {
+	locks *int
+	Data  []int
+}
+
+func (c *lockCounter) Lock()   { *c.locks++ }
+func (c *lockCounter) Unlock() {}
+
+func TestCopyIncrementalOptions(t *testing.T) {
+	type list struct {
+		Next *list
+	}
+	var deep *list
+	for i := 0; i < 50; i++ {
+		deep = &list{Next: deep}
+	}
+	prev := MustClone(deep)
+	if _, err := CopyIncremental(deep, prev, MustClone(deep), WithLimits(Limits{MaxDepth: 5})); err == nil {
+		t.Fatal("expected the depth limit to be enforced on the compared values")
+	}
+
+	locks := 0
+	cur := &struct{ C lockCounter }{lockCounter{locks: &locks, Data: []int{1}}}
+	if _, err := CopyIncremental(cur, MustClone(cur), cur, LockSource()); err != nil {
+		t.Fatal(err)
+	}
+	if locks != 1 {
+		t.Fatalf("got %d locks, expected the source to be locked once", locks)
+	}
+}
@@ -0,0 +1,167 @@
+package gentest
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gadumitrachioaiei/deepcopy"
+)
+
+func newConfig() *Config {
+	updated := time.Now()
+	labels := map[string]string{"a": "b"}
+	row := []int{2, 3}
+	primary := &Item{ID: 3}
+	ring := &Ring{Value: 1, Next: &Ring{Value: 2}}
+	ring.Prev, ring.Next.Prev, ring.Next.Next = ring.Next, ring, ring
+	c := &Config{
+		Name:    "config",
+		Port:    80,
+		Tags:    make([]string, 1, 4),
+		Labels:  labels,
+		Aliases: labels,
+		Matrix:  [][]int{{1}, nil, row, row[:1]},
+		Grid:    [2][3]int{{1, 2, 3}},
+		Nested:  map[string][]*Item{"a": {{ID: 1}, primary}, "b": nil},
+		Items:   []Item{{ID: 2, Data: []byte("data"), Meta: map[string]interface{}{"k": []int{1}}}},
+		Primary: primary,
+		Point:   Point{X: 1},
+		Points:  []Point{{Y: 2}},
+		Keys:    map[*Key]int{{K: "k"}: 1},
+		Any:     &Item{ID: 4},
+		Created: time.Now(),
+		Updated: &updated,
+		Events:  make(chan int),
+		Header:  http.Header{"A": {"b"}},
+		Tree:    &Node{Value: 1, Next: &Node{Value: 2, Next: &Node{Value: 3}}},
+		Ring:    ring,
+		List:    List{{ID: 5}, nil, primary},
+		Skipped: []int{1},
+		Shared:  []int{2},
+		Queue:   make(chan *Item, 2),
+		Guard:   new(sync.RWMutex),
+		Store:   Store{Data: map[string]int{"a": 1}},
+		secret:  "secret",
+		cache:   map[string]int{"a": 1},
+	}
+	c.Tags[0] = "tag"
+	c.Inline.A = 1
+	c.Inline.B = []byte("inline")
+	c.Queue <- &Item{ID: 6}
+	c.Mu.Lock()
+	c.Locked.Lock()
+	c.Guard.Lock()
+	c.Store.Lock()
+	return c
+}
+
+// TestGeneratedMatchesCopy checks the generated methods against deepcopy.Copy, which serves as the oracle.
+func TestGeneratedMatchesCopy(t *testing.T) {
+	c := newConfig()
+	generated := c.DeepCopy()
+	vi, err := deepcopy.Copy(c, deepcopy.IgnoreMethods())
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := vi.(*Config)
+
+	// the deep copied channel and the map with pointer keys cannot be compared by reflect.DeepEqual
+	for _, v := range []*Config{generated, copied} {
+		if v.Queue == c.Queue || len(v.Queue) != 1 || cap(v.Queue) != 2 {
+			t.Fatalf("got queue with len %d and cap %d, expected a new channel", len(v.Queue), cap(v.Queue))
+		}
+		if item := <-v.Queue; item.ID != 6 {
+			t.Fatalf("got queued item: %+v, expected a copy", item)
+		}
+		if len(v.Keys) != 1 {
+			t.Fatalf("got keys: %v, expected one key", v.Keys)
+		}
+		for k, n := range v.Keys {
+			if k.K != "k" || n != 1 {
+				t.Fatalf("got key: %+v, value %d, expected a copy", k, n)
+			}
+		}
+		v.Queue, v.Keys = nil, nil
+	}
+	if !reflect.DeepEqual(generated, copied) {
+		t.Fatalf("generated copy:\n%#v\ndiffers from deepcopy.Copy:\n%#v", generated, copied)
+	}
+	if generated.secret != "" || generated.cache["a"] != 1 || generated.Skipped != nil {
+		t.Fatalf("got: %+v, expected the unexported field and the tags to be honored", generated)
+	}
+	if &generated.Shared[0] != &c.Shared[0] || generated.Events != c.Events {
+		t.Fatal("shallow copied slice and channel are not shared")
+	}
+	if !generated.Mu.TryLock() || !generated.Locked.TryLock() || generated.Guard == c.Guard || !generated.Guard.TryLock() {
+		t.Fatal("expected the locks to be reset")
+	}
+	if generated.Store.Data["a"] != 1 || &generated.Store.Data == &c.Store.Data || !generated.Store.mu.TryLock() {
+		t.Fatalf("got store data: %v, expected it to be copied and the lock to be reset", generated.Store.Data)
+	}
+	for _, v := range []*Config{generated, copied} {
+		if v.Nested["a"][1] != v.Primary || v.List[2] != v.Primary || v.Primary == c.Primary {
+			t.Fatal("expected the copies of the shared pointer to be shared")
+		}
+		if reflect.ValueOf(v.Aliases).Pointer() != reflect.ValueOf(v.Labels).Pointer() || &v.Matrix[2][0] != &v.Matrix[3][0] {
+			t.Fatal("expected the copies of the shared map and backing array to be shared")
+		}
+		if v.Ring.Next.Next != v.Ring || v.Ring.Prev != v.Ring.Next || v.Ring.Next.Prev != v.Ring || v.Ring == c.Ring {
+			t.Fatal("expected the ring to be copied with its cycle")
+		}
+	}
+	if &generated.Tags[0] == &c.Tags[0] || generated.Items[0].Meta["k"].([]int)[0] != 1 || generated.Tree.Next.Next == c.Tree.Next.Next {
+		t.Fatal("generated copy shares memory with the original")
+	}
+}
+
+// TestGeneratedSubslices checks the slices and pointers into a backing array share its copy, like with deepcopy.Copy.
+func TestGeneratedSubslices(t *testing.T) {
+	items := []Item{{ID: 1}, {ID: 2, Data: []byte("data")}, {ID: 3}}
+	bytes := []byte("bytes")
+	w := &Window{All: items, Tail: items[1:], Last: &items[2], Bytes: bytes, Middle: bytes[1:3], Byte: &bytes[4]}
+	generated := w.DeepCopy()
+	vi, err := deepcopy.Copy(w, deepcopy.IgnoreMethods())
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := vi.(*Window)
+	if !reflect.DeepEqual(generated, copied) {
+		t.Fatalf("generated copy:\n%#v\ndiffers from deepcopy.Copy:\n%#v", generated, copied)
+	}
+	for _, v := range []*Window{generated, copied} {
+		if &v.Tail[0] != &v.All[1] || v.Last != &v.All[2] || &v.Middle[0] != &v.Bytes[1] || v.Byte != &v.Bytes[4] {
+			t.Fatal("expected the subslices and the pointers to share the copy of their backing array")
+		}
+		if &v.All[0] == &items[0] || &v.Bytes[0] == &bytes[0] || &v.All[1].Data[0] == &items[1].Data[0] {
+			t.Fatal("expected a deep copy")
+		}
+	}
+}
+
+func TestGeneratedIntoDirty(t *testing.T) {
+	out := newConfig()
+	(&Config{Name: "clean"}).DeepCopyInto(out)
+	if !reflect.DeepEqual(out, &Config{Name: "clean"}) {
+		t.Fatalf("got: %+v, expected the destination to be overwritten", out)
+	}
+	l := List{{ID: 1}}
+	var nl List
+	nl.DeepCopyInto(&l)
+	if l != nil {
+		t.Fatalf("got: %v, expected nil", l)
+	}
+}
+
+// TestMethodsUsedByCopy checks that deepcopy.Copy calls the generated methods.
+func TestMethodsUsedByCopy(t *testing.T) {
+	v, err := deepcopy.Clone(map[string]List{"a": {{ID: 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v["a"][0].ID != 1 {
+		t.Fatalf("got: %v, expected a copy", v)
+	}
+}
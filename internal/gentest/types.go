@@ -0,0 +1,107 @@
+// Package gentest holds the types that check the code generated by deepcopy-gen copies like deepcopy.Copy.
+package gentest
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+//go:generate go run ../../cmd/deepcopy-gen
+
+// Config uses every kind of field deepcopy-gen knows about.
+//
+//deepcopy:generate
+type Config struct {
+	Name    string
+	Port    int
+	Tags    []string
+	Labels  map[string]string
+	Aliases map[string]string
+	Matrix  [][]int
+	Grid    [2][3]int
+	Nested  map[string][]*Item
+	Items   []Item
+	Primary *Item
+	Point   Point
+	Points  []Point
+	Inline  struct {
+		A int
+		B []byte
+	}
+	Keys    map[*Key]int
+	Any     interface{}
+	Created time.Time
+	Updated *time.Time
+	Events  chan int
+	Hook    func() string
+	Header  http.Header
+	Tree    *Node
+	Ring    *Ring
+	List    List
+	Skipped []int      `deepcopy:"-"`
+	Shared  []int      `deepcopy:"shallow"`
+	Queue   chan *Item `deepcopy:"deep"`
+	Mu      sync.Mutex
+	Guard   *sync.RWMutex
+	Locked  sync.Mutex `deepcopy:"shallow"`
+	Store   Store
+	secret  string
+	cache   map[string]int `deepcopy:"deep"`
+}
+
+//deepcopy:generate
+type Item struct {
+	ID   int
+	Data []byte
+	Meta map[string]interface{}
+}
+
+// List is a named slice with generated methods.
+//
+//deepcopy:generate
+type List []*Item
+
+// Window holds slices starting at different elements of the same backing arrays, and pointers to their elements.
+//
+//deepcopy:generate
+type Window struct {
+	All    []Item
+	Tail   []Item
+	Last   *Item
+	Bytes  []byte
+	Middle []byte
+	Byte   *byte
+}
+
+// Point is copied by assignment.
+type Point struct {
+	X, Y float64
+}
+
+// Store guards its data with its own Lock and Unlock methods, it is copied with its lock reset.
+type Store struct {
+	mu   sync.Mutex
+	Data map[string]int
+}
+
+func (s *Store) Lock()   { s.mu.Lock() }
+func (s *Store) Unlock() { s.mu.Unlock() }
+
+type Key struct {
+	K string
+}
+
+// Ring is a doubly linked ring with generated methods, whose cycles the generated code copies.
+//
+//deepcopy:generate
+type Ring struct {
+	Value      int
+	Prev, Next *Ring
+}
+
+// Node is recursive and has no generated methods, so the generated code copies it with deepcopy.MustClone.
+type Node struct {
+	Value int
+	Next  *Node
+}
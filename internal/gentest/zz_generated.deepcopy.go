@@ -0,0 +1,493 @@
+// Code generated by deepcopy-gen. DO NOT EDIT.
+
+package gentest
+
+import (
+	"github.com/gadumitrachioaiei/deepcopy"
+	"reflect"
+)
+
+// DeepCopyInto copies the receiver into out, which must not be nil.
+func (in *Config) DeepCopyInto(out *Config) {
+	*out = Config{}
+	in.deepCopyInto(out, deepcopyRefs{in: out})
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *Config) DeepCopy() *Config {
+	if in == nil {
+		return nil
+	}
+	out := new(Config)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Config) deepCopyInto(out *Config, refs deepcopyRefs) {
+	out.Name = in.Name
+	out.Port = in.Port
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		deepcopySlice(refs, out, *in)
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		if deepcopyMap(refs, out, *in) {
+			for key, val := range *in {
+				(*out)[key] = val
+			}
+		}
+	}
+	if in.Aliases != nil {
+		in, out := &in.Aliases, &out.Aliases
+		if deepcopyMap(refs, out, *in) {
+			for key, val := range *in {
+				(*out)[key] = val
+			}
+		}
+	}
+	if in.Matrix != nil {
+		in, out := &in.Matrix, &out.Matrix
+		elems := deepcopySlice(refs, out, *in)
+		for i := range *in {
+			if elems.fill(i) {
+				if (*in)[i] != nil {
+					in, out := &(*in)[i], &(*out)[i]
+					deepcopySlice(refs, out, *in)
+					copy(*out, *in)
+				}
+			}
+		}
+	}
+	out.Grid = in.Grid
+	if in.Nested != nil {
+		in, out := &in.Nested, &out.Nested
+		if deepcopyMap(refs, out, *in) {
+			for key, val := range *in {
+				var newVal []*Item
+				if val != nil {
+					in, out := &val, &newVal
+					elems := deepcopySlice(refs, out, *in)
+					for i := range *in {
+						if elems.fill(i) {
+							if (*in)[i] != nil {
+								in, out := &(*in)[i], &(*out)[i]
+								if deepcopyPointer(refs, out, *in) {
+									(**in).deepCopyInto(*out, refs)
+								}
+							}
+						}
+					}
+				}
+				(*out)[key] = newVal
+			}
+		}
+	}
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		elems := deepcopySlice(refs, out, *in)
+		for i := range *in {
+			if elems.fill(i) {
+				(*in)[i].deepCopyInto(&(*out)[i], refs)
+			}
+		}
+	}
+	if in.Primary != nil {
+		in, out := &in.Primary, &out.Primary
+		if deepcopyPointer(refs, out, *in) {
+			(**in).deepCopyInto(*out, refs)
+		}
+	}
+	out.Point = in.Point
+	if in.Points != nil {
+		in, out := &in.Points, &out.Points
+		deepcopySlice(refs, out, *in)
+		copy(*out, *in)
+	}
+	out.Inline.A = in.Inline.A
+	if in.Inline.B != nil {
+		in, out := &in.Inline.B, &out.Inline.B
+		deepcopySlice(refs, out, *in)
+		copy(*out, *in)
+	}
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		if deepcopyMap(refs, out, *in) {
+			for key, val := range *in {
+				var newKey *Key
+				if key != nil {
+					in, out := &key, &newKey
+					if deepcopyPointer(refs, out, *in) {
+						**out = **in
+					}
+				}
+				(*out)[newKey] = val
+			}
+		}
+	}
+	out.Any = deepcopy.MustClone(in.Any)
+	out.Created = in.Created
+	if in.Updated != nil {
+		in, out := &in.Updated, &out.Updated
+		if deepcopyPointer(refs, out, *in) {
+			**out = **in
+		}
+	}
+	out.Events = in.Events
+	out.Hook = in.Hook
+	if in.Header != nil {
+		deepcopyCall(refs, &out.Header, in.Header, in.Header.Clone)
+	}
+	if in.Tree != nil {
+		in, out := &in.Tree, &out.Tree
+		if deepcopyPointer(refs, out, *in) {
+			(*out).Value = (*in).Value
+			if (*in).Next != nil {
+				in, out := &(*in).Next, &(*out).Next
+				if deepcopyPointer(refs, out, *in) {
+					**out = deepcopy.MustClone(**in)
+				}
+			}
+		}
+	}
+	if in.Ring != nil {
+		in, out := &in.Ring, &out.Ring
+		if deepcopyPointer(refs, out, *in) {
+			(**in).deepCopyInto(*out, refs)
+		}
+	}
+	in.List.deepCopyInto(&out.List, refs)
+	out.Shared = in.Shared
+	if in.Queue != nil {
+		in, out := &in.Queue, &out.Queue
+		*out = make(chan *Item, cap(*in))
+		for n := len(*in); n > 0; n-- {
+			e := <-*in
+			*in <- e
+			var newE *Item
+			if e != nil {
+				in, out := &e, &newE
+				if deepcopyPointer(refs, out, *in) {
+					(**in).deepCopyInto(*out, refs)
+				}
+			}
+			*out <- newE
+		}
+	}
+	if in.Guard != nil {
+		deepcopyPointer(refs, &out.Guard, in.Guard)
+	}
+	if in.Store.Data != nil {
+		in, out := &in.Store.Data, &out.Store.Data
+		if deepcopyMap(refs, out, *in) {
+			for key, val := range *in {
+				(*out)[key] = val
+			}
+		}
+	}
+	if in.cache != nil {
+		in, out := &in.cache, &out.cache
+		if deepcopyMap(refs, out, *in) {
+			for key, val := range *in {
+				(*out)[key] = val
+			}
+		}
+	}
+}
+
+// DeepCopyInto copies the receiver into out, which must not be nil.
+func (in *Item) DeepCopyInto(out *Item) {
+	*out = Item{}
+	in.deepCopyInto(out, deepcopyRefs{in: out})
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *Item) DeepCopy() *Item {
+	if in == nil {
+		return nil
+	}
+	out := new(Item)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Item) deepCopyInto(out *Item, refs deepcopyRefs) {
+	out.ID = in.ID
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		deepcopySlice(refs, out, *in)
+		copy(*out, *in)
+	}
+	if in.Meta != nil {
+		in, out := &in.Meta, &out.Meta
+		if deepcopyMap(refs, out, *in) {
+			for key, val := range *in {
+				var newVal interface{}
+				newVal = deepcopy.MustClone(val)
+				(*out)[key] = newVal
+			}
+		}
+	}
+}
+
+// DeepCopyInto copies the receiver into out, which must not be nil.
+func (in *List) DeepCopyInto(out *List) {
+	*out = nil
+	in.deepCopyInto(out, deepcopyRefs{in: out})
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *List) DeepCopy() *List {
+	if in == nil {
+		return nil
+	}
+	out := new(List)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *List) deepCopyInto(out *List, refs deepcopyRefs) {
+	if *in != nil {
+		elems := deepcopySlice(refs, out, *in)
+		for i := range *in {
+			if elems.fill(i) {
+				if (*in)[i] != nil {
+					in, out := &(*in)[i], &(*out)[i]
+					if deepcopyPointer(refs, out, *in) {
+						(**in).deepCopyInto(*out, refs)
+					}
+				}
+			}
+		}
+	}
+}
+
+// DeepCopyInto copies the receiver into out, which must not be nil.
+func (in *Window) DeepCopyInto(out *Window) {
+	*out = Window{}
+	in.deepCopyInto(out, deepcopyRefs{in: out})
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *Window) DeepCopy() *Window {
+	if in == nil {
+		return nil
+	}
+	out := new(Window)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Window) deepCopyInto(out *Window, refs deepcopyRefs) {
+	if in.All != nil {
+		in, out := &in.All, &out.All
+		elems := deepcopySlice(refs, out, *in)
+		for i := range *in {
+			if elems.fill(i) {
+				(*in)[i].deepCopyInto(&(*out)[i], refs)
+			}
+		}
+	}
+	if in.Tail != nil {
+		in, out := &in.Tail, &out.Tail
+		elems := deepcopySlice(refs, out, *in)
+		for i := range *in {
+			if elems.fill(i) {
+				(*in)[i].deepCopyInto(&(*out)[i], refs)
+			}
+		}
+	}
+	if in.Last != nil {
+		in, out := &in.Last, &out.Last
+		if deepcopyPointer(refs, out, *in) {
+			(**in).deepCopyInto(*out, refs)
+		}
+	}
+	if in.Bytes != nil {
+		in, out := &in.Bytes, &out.Bytes
+		deepcopySlice(refs, out, *in)
+		copy(*out, *in)
+	}
+	if in.Middle != nil {
+		in, out := &in.Middle, &out.Middle
+		deepcopySlice(refs, out, *in)
+		copy(*out, *in)
+	}
+	if in.Byte != nil {
+		in, out := &in.Byte, &out.Byte
+		if deepcopyPointer(refs, out, *in) {
+			**out = **in
+		}
+	}
+}
+
+// DeepCopyInto copies the receiver into out, which must not be nil.
+func (in *Ring) DeepCopyInto(out *Ring) {
+	*out = Ring{}
+	in.deepCopyInto(out, deepcopyRefs{in: out})
+}
+
+// DeepCopy returns a deep copy of the receiver.
+func (in *Ring) DeepCopy() *Ring {
+	if in == nil {
+		return nil
+	}
+	out := new(Ring)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Ring) deepCopyInto(out *Ring, refs deepcopyRefs) {
+	out.Value = in.Value
+	if in.Prev != nil {
+		in, out := &in.Prev, &out.Prev
+		if deepcopyPointer(refs, out, *in) {
+			(**in).deepCopyInto(*out, refs)
+		}
+	}
+	if in.Next != nil {
+		in, out := &in.Next, &out.Next
+		if deepcopyPointer(refs, out, *in) {
+			(**in).deepCopyInto(*out, refs)
+		}
+	}
+}
+
+// deepcopyRefs maps the pointers, maps and backing arrays of the original to their copies.
+type deepcopyRefs map[interface{}]interface{}
+
+// deepcopyKey is the key in deepcopyRefs of the map of type T at the address ptr,
+// or of the deepcopyArrays of type T when ptr is zero.
+type deepcopyKey[T any] struct {
+	ptr uintptr
+}
+
+// deepcopyArray is the copy of the backing array of the original at the address start,
+// of which the elements marked as filled are copied, or being copied.
+// It spans the capacity of the first slice found on the array.
+type deepcopyArray[E any] struct {
+	start  uintptr
+	elems  []E
+	filled []bool
+}
+
+// deepcopyArrays are the copies of the backing arrays of elements of type E, sorted by address,
+// so the slices of a backing array and the pointers to its elements share its copy.
+type deepcopyArrays[E any] []*deepcopyArray[E]
+
+// find returns the index of the array holding the address ptr, or of the first one after it,
+// and reports whether it holds it. The elements have the size size.
+func (as deepcopyArrays[E]) find(ptr, size uintptr) (int, bool) {
+	lo, hi := 0, len(as)
+	for lo < hi {
+		if m := (lo + hi) / 2; as[m].start > ptr {
+			hi = m
+		} else {
+			lo = m + 1
+		}
+	}
+	if lo > 0 && ptr < as[lo-1].start+uintptr(len(as[lo-1].elems))*size {
+		return lo - 1, true
+	}
+	return lo, false
+}
+
+// deepcopyElems are the elements of a slice of the original, found at offset in the copy of its backing array,
+// if it has one.
+type deepcopyElems[E any] struct {
+	array  *deepcopyArray[E]
+	offset int
+}
+
+// fill reports whether the element i of the slice is not copied yet, and marks it as copied.
+func (e deepcopyElems[E]) fill(i int) bool {
+	if e.array == nil {
+		return true
+	}
+	if e.array.filled[e.offset+i] {
+		return false
+	}
+	e.array.filled[e.offset+i] = true
+	return true
+}
+
+// deepcopyPointer sets *out to the copy of the pointer in and reports whether the value it points to
+// is not copied yet, so the caller copies it.
+// A pointer to an element of a copied backing array points to the copy of the element.
+func deepcopyPointer[T any](refs deepcopyRefs, out **T, in *T) bool {
+	if c, ok := refs[in]; ok {
+		*out = c.(*T)
+		return false
+	}
+	if size := reflect.TypeOf(in).Elem().Size(); size > 0 {
+		as, _ := refs[deepcopyKey[[]T]{}].(deepcopyArrays[T])
+		ptr := reflect.ValueOf(in).Pointer()
+		if i, ok := as.find(ptr, size); ok {
+			e := deepcopyElems[T]{as[i], int((ptr - as[i].start) / size)}
+			*out = &e.array.elems[e.offset]
+			refs[in] = *out
+			return e.fill(0)
+		}
+	}
+	*out = new(T)
+	refs[in] = *out
+	return true
+}
+
+// deepcopyMap sets *out to the copy of the map in and reports whether it is a new one,
+// whose entries the caller copies.
+func deepcopyMap[M ~map[K]V, K comparable, V any](refs deepcopyRefs, out *M, in M) bool {
+	key := deepcopyKey[M]{reflect.ValueOf(in).Pointer()}
+	if c, ok := refs[key]; ok {
+		*out = c.(M)
+		return false
+	}
+	*out = make(M, len(in))
+	refs[key] = *out
+	return true
+}
+
+// deepcopySlice sets *out to the copy of the slice in and returns its elements, of which the caller copies the ones to fill.
+// The slices of a backing array share its copy, unless they reach past it or over the copy of another one.
+// The elements of in must not be of size zero, as they could not tell the backing arrays apart.
+func deepcopySlice[S ~[]E, E any](refs deepcopyRefs, out *S, in S) deepcopyElems[E] {
+	if cap(in) == 0 {
+		*out = make(S, len(in))
+		return deepcopyElems[E]{}
+	}
+	key := deepcopyKey[[]E]{}
+	as, _ := refs[key].(deepcopyArrays[E])
+	size := reflect.TypeOf(in).Elem().Size()
+	start := reflect.ValueOf(in).Pointer()
+	end := start + uintptr(cap(in))*size
+	i, ok := as.find(start, size)
+	switch {
+	case ok && end <= as[i].start+uintptr(len(as[i].elems))*size:
+		e := deepcopyElems[E]{as[i], int((start - as[i].start) / size)}
+		*out = S(e.array.elems[e.offset : e.offset+len(in) : e.offset+cap(in)])
+		return e
+	case ok || i < len(as) && as[i].start < end:
+		*out = make(S, len(in), cap(in))
+		return deepcopyElems[E]{}
+	}
+	a := &deepcopyArray[E]{start: start, elems: make([]E, cap(in)), filled: make([]bool, cap(in))}
+	as = append(as, nil)
+	copy(as[i+1:], as[i:])
+	as[i] = a
+	refs[key] = as
+	*out = S(a.elems[:len(in)])
+	return deepcopyElems[E]{array: a}
+}
+
+// deepcopyCall sets *out to the copy the function copy makes of the pointer or the map in,
+// unless one was made before.
+func deepcopyCall[T any](refs deepcopyRefs, out *T, in T, copy func() T) {
+	key := deepcopyKey[T]{reflect.ValueOf(in).Pointer()}
+	if c, ok := refs[key]; ok {
+		*out = c.(T)
+		return
+	}
+	*out = copy()
+	refs[key] = *out
+}
@@ -0,0 +1,250 @@
+package deepcopy
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// CopyInto overwrites the value dst points to with a deep copy of src, following the same rules as Copy.
+// Unlike Copy, it reuses the memory the old value of *dst references where it can:
+// values pointed to are overwritten, slices with enough capacity are refilled and maps are cleared and refilled.
+// The other slices on the backing array of a refilled slice, and the pointers to its elements, share it like in a copy.
+// This means anything else sharing that memory sees the new value as well.
+// Memory that src and *dst share is not reused, but copied anew.
+// dst must be a non nil pointer and src must be assignable to the type dst points to.
+func CopyInto(dst, src interface{}, opts ...Option) error {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return fmt.Errorf("deepcopy: CopyInto destination must be a non nil pointer, not %T", dst)
+	}
+	dv = dv.Elem()
+	sv := reflect.ValueOf(src)
+	if !sv.IsValid() {
+		dv.SetZero()
+		return nil
+	}
+	if !sv.Type().AssignableTo(dv.Type()) {
+		return fmt.Errorf("deepcopy: CopyInto cannot copy %s into %s", sv.Type(), dv.Type())
+	}
+	sc := scratches.Get().(*scratch)
+	defer sc.release()
+	c := newCopier(opts)
+	if c.shards == nil {
+		c.visited = sc.visited
+	}
+	sourceSpans(sv, &sc.sources, sc.walked)
+	c.sources = &sc.sources
+	clear(sc.walked)
+	c.reused = sc.walked
+	if sv.Type() != dv.Type() {
+		oc, err := c.copyr(sv)
+		if err != nil {
+			return err
+		}
+		dv.Set(oc)
+		return nil
+	}
+	return c.copyInto(dv, sv, planFor(sv.Type()))
+}
+
+// scratch holds the maps and spans of one CopyInto, kept for the next ones, as CopyInto is meant to be called in hot loops.
+type scratch struct {
+	walked  map[visit]bool
+	visited map[visit]reflect.Value
+	sources spans
+}
+
+var scratches = sync.Pool{New: func() interface{} {
+	return &scratch{walked: make(map[visit]bool), visited: make(map[visit]reflect.Value)}
+}}
+
+// release empties sc, so it references nothing, and puts it back in the pool.
+func (sc *scratch) release() {
+	clear(sc.walked)
+	clear(sc.visited)
+	sc.sources.list = sc.sources.list[:0]
+	scratches.Put(sc)
+}
+
+// copyInto deep copies ov into the addressable value dv of the same type, following the plan p.
+// It reuses the references of dv where it can.
+func (c *copier) copyInto(dv, ov reflect.Value, p *plan) error {
+	if p.primitive || p.lock || p.sensitive || p.copier != nil || p.method.kind != noMethod && !c.opts.ignoreMethods || !c.reusable(dv, ov, p) {
+		return c.setCopy(dv, ov, p)
+	}
+	if c.opts.transform != nil {
+		if oc, handled, err := c.transform(ov); handled || err != nil {
+			if err != nil {
+				return err
+			}
+			dv.Set(oc)
+			return nil
+		}
+	}
+	if c.opts.limits != (Limits{}) {
+		err := c.enter()
+		defer c.leave()
+		if err != nil {
+			return err
+		}
+	}
+	if p.locker != noLocker && c.opts.lockSource {
+		if unlock := lock(ov, p.locker); unlock != nil {
+			defer unlock()
+		}
+	}
+	switch p.strategy {
+	case structStrategy:
+		return c.copyFields(dv, ov, p, true)
+	case pointerStrategy:
+		// we register the reused pointer before copying the element, so cycles end up here
+		oc := dv.Elem().Addr()
+		c.register(visit{ptr: ov.Pointer(), typ: p.typ}, oc)
+		return c.copyInto(oc.Elem(), ov.Elem(), p.elem)
+	case sliceStrategy:
+		return c.copySliceInto(dv, ov, p)
+	case mapStrategy:
+		dv.Clear()
+		c.register(visit{ptr: ov.Pointer(), typ: p.typ}, reflect.ValueOf(dv.Interface()))
+		return c.fillMap(dv, ov, p)
+	}
+	// arrays
+	for i := 0; i < ov.Len(); i++ {
+		c.path.push(PathStep{Kind: IndexStep, Index: i})
+		err := c.copyInto(dv.Index(i), ov.Index(i), p.elem)
+		c.path.pop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setCopy sets dv to a new deep copy of ov.
+func (c *copier) setCopy(dv, ov reflect.Value, p *plan) error {
+	oc, err := c.copyPlan(ov, p)
+	if err != nil {
+		return err
+	}
+	dv.Set(oc)
+	return nil
+}
+
+// reusable reports whether ov can be copied into dv, reusing what dv references,
+// and marks the reference of dv as reused.
+// Structs and arrays are always copied into dv, while the kinds that are not copied at all never are.
+func (c *copier) reusable(dv, ov reflect.Value, p *plan) bool {
+	switch p.strategy {
+	case structStrategy, arrayStrategy:
+		return true
+	case pointerStrategy, mapStrategy:
+		if ov.IsNil() {
+			return false
+		}
+		if _, ok := c.seen(visit{ptr: ov.Pointer(), typ: p.typ}); ok {
+			return false
+		}
+		if p.strategy == pointerStrategy {
+			// a pointer to an element of a backing array copied before points into its copy
+			if r, _ := c.element(ov, p); r != nil {
+				return false
+			}
+		}
+	case sliceStrategy:
+		if ov.IsNil() || dv.Cap() < ov.Len() {
+			return false
+		}
+		if _, ok := c.seen(visit{ptr: ov.Pointer(), typ: p.typ, len: ov.Len(), cap: ov.Cap()}); ok {
+			return false
+		}
+		// and so does a slice on it
+		if r, _ := c.element(ov, p); r != nil {
+			return false
+		}
+	default:
+		return false
+	}
+	if dv.IsNil() {
+		return false
+	}
+	// memory src references would be overwritten while it is still being copied
+	if start, end, ok := extent(dv); ok {
+		if _, shared := c.sources.overlap(start, end); shared {
+			return false
+		}
+	}
+	key := visit{ptr: dv.Pointer(), typ: dv.Type()}
+	if c.reused[key] {
+		return false
+	}
+	c.reused[key] = true
+	return true
+}
+
+// sourceSpans adds the memory ov references, which CopyInto must not reuse, to s, and marks the references it walks in walked.
+func sourceSpans(ov reflect.Value, s *spans, walked map[visit]bool) {
+	w := sharing{walked: walked}
+	w.walk(ov, func(v reflect.Value) bool {
+		if v.Kind() == reflect.Func || v.Kind() == reflect.Chan {
+			// they hold no memory the copy could reuse
+			return false
+		}
+		key, ok := reference(v)
+		if !ok {
+			return true
+		}
+		if w.walked[key] {
+			return false
+		}
+		w.walked[key] = true
+		if start, end, ok := extent(v); ok {
+			s.add(start, end, nil)
+		}
+		return true
+	})
+	s.index()
+}
+
+// extent returns the range of memory the pointer, map or slice v references, and reports whether it has one.
+// The range of a map is its first byte, as its entries move around.
+func extent(v reflect.Value) (uintptr, uintptr, bool) {
+	var size uintptr
+	switch v.Kind() {
+	case reflect.Ptr:
+		size = v.Type().Elem().Size()
+	case reflect.Map:
+		size = 1
+	case reflect.Slice:
+		size = uintptr(v.Cap()) * v.Type().Elem().Size()
+	}
+	if size == 0 || v.IsNil() {
+		return 0, 0, false
+	}
+	return v.Pointer(), v.Pointer() + size, true
+}
+
+func (c *copier) copySliceInto(dv, ov reflect.Value, p *plan) error {
+	n := ov.Len()
+	if old := dv.Len(); old > n {
+		// the elements past the new length must not keep references alive
+		dv.Slice(n, old).Clear()
+	}
+	dv.SetLen(n)
+	c.register(visit{ptr: ov.Pointer(), typ: p.typ, len: n, cap: ov.Cap()}, dv)
+	// the other slices on the backing array share the reused one, like in a copy
+	c.reuse(ov, dv, p)
+	if p.elem.primitive && c.opts.transform == nil {
+		reflect.Copy(dv, ov)
+		return nil
+	}
+	for i := 0; i < n; i++ {
+		c.path.push(PathStep{Kind: IndexStep, Index: i})
+		err := c.copyInto(dv.Index(i), ov.Index(i), p.elem)
+		c.path.pop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package deepcopy
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type intoInner struct {
+	N []int
+}
+
+type intoT struct {
+	Items  []*intoInner
+	Index  map[string]int
+	Ptr    *intoInner
+	Skip   []int `deepcopy:"-"`
+	hidden []int
+}
+
+func TestCopyInto(t *testing.T) {
+	src := intoT{
+		Items:  []*intoInner{{N: []int{1, 2}}, {N: []int{3}}},
+		Index:  map[string]int{"a": 1},
+		Ptr:    &intoInner{N: []int{4}},
+		Skip:   []int{5},
+		hidden: []int{6},
+	}
+	items := make([]*intoInner, 3, 8)
+	items[2] = &intoInner{N: []int{9}}
+	ptr := &intoInner{N: make([]int, 0, 4)}
+	index := map[string]int{"old": 7}
+	dst := intoT{Items: items, Index: index, Ptr: ptr, Skip: []int{8}, hidden: []int{9}}
+	if err := CopyInto(&dst, src); err != nil {
+		t.Fatal(err)
+	}
+	want := intoT{Items: src.Items, Index: src.Index, Ptr: src.Ptr}
+	if diff := cmp.Diff(want, dst, cmp.AllowUnexported(intoT{})); diff != "" {
+		t.Fatal(diff)
+	}
+	if &dst.Items[0] != &items[0] {
+		t.Fatal("expected the slice backing array to be reused")
+	}
+	if items[:3][2] != nil {
+		t.Fatal("expected the elements past the new length to be cleared")
+	}
+	if dst.Ptr != ptr || &dst.Ptr.N[:1][0] != &ptr.N[:1][0] {
+		t.Fatal("expected the pointed value and its slice to be reused")
+	}
+	index["b"] = 2
+	if dst.Index["b"] != 2 {
+		t.Fatal("expected the map to be reused")
+	}
+	if dst.Items[0] == src.Items[0] || dst.Ptr == src.Ptr {
+		t.Fatal("expected a deep copy")
+	}
+}
+
+func TestCopyIntoUnexported(t *testing.T) {
+	src := intoT{hidden: []int{1, 2}}
+	hidden := make([]int, 0, 2)
+	dst := intoT{hidden: hidden}
+	if err := CopyInto(&dst, src, WithUnexported(UnexportedDeep)); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(src, dst, cmp.AllowUnexported(intoT{})); diff != "" {
+		t.Fatal(diff)
+	}
+	if &dst.hidden[0] != &hidden[:1][0] {
+		t.Fatal("expected the unexported slice to be reused")
+	}
+}
+
+func TestCopyIntoShared(t *testing.T) {
+	shared := &intoInner{N: []int{1}}
+	src := intoT{Items: []*intoInner{shared, shared}, Ptr: shared}
+	// the destination holds the same pointer twice, it must not be filled with two different values
+	old := &intoInner{}
+	dst := intoT{Items: []*intoInner{old, old}, Ptr: &intoInner{}}
+	if err := CopyInto(&dst, src); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Items[0] != dst.Items[1] || dst.Items[0] != dst.Ptr {
+		t.Fatal("expected the shared pointer to stay shared")
+	}
+	if dst.Items[0] == shared {
+		t.Fatal("expected a deep copy")
+	}
+}
+
+func TestCopyIntoSameMemory(t *testing.T) {
+	src := intoT{Items: []*intoInner{{N: []int{1}}}, Index: map[string]int{"a": 1}}
+	dst := src
+	if err := CopyInto(&dst, src); err != nil {
+		t.Fatal(err)
+	}
+	if &dst.Items[0] == &src.Items[0] || dst.Items[0] == src.Items[0] {
+		t.Fatal("expected memory shared with the source to be copied anew")
+	}
+	dst.Index["b"] = 2
+	if _, ok := src.Index["b"]; ok {
+		t.Fatal("expected the map shared with the source to be copied anew")
+	}
+}
+
+// TestCopyIntoSubslices checks the slices and pointers on the backing array of a reused slice share it, like in a copy.
+func TestCopyIntoSubslices(t *testing.T) {
+	type S struct {
+		A, B []intoInner
+		P    *intoInner
+	}
+	s := []intoInner{{N: []int{1}}, {N: []int{2}}, {N: []int{3}}}
+	src := S{A: s, B: s[1:], P: &s[2]}
+	reused := make([]intoInner, 0, 4)
+	dst := S{A: reused, B: make([]intoInner, 0, 4), P: &intoInner{}}
+	if err := CopyInto(&dst, src); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(src, dst); diff != "" {
+		t.Fatal(diff)
+	}
+	if &dst.A[0] != &reused[:1][0] || &dst.B[0] != &dst.A[1] || dst.P != &dst.A[2] {
+		t.Fatal("expected the subslice and the pointer to share the reused backing array")
+	}
+}
+
+// TestCopyIntoCrossShared checks memory *dst shares with another part of the source is not reused either.
+func TestCopyIntoCrossShared(t *testing.T) {
+	type S struct {
+		A, B map[int]int
+		P    *intoInner
+		N    []int
+	}
+	inner := &struct{ In intoInner }{intoInner{N: []int{1, 2}}}
+	src := S{A: map[int]int{1: 1}, B: map[int]int{2: 2}, P: &inner.In, N: []int{3}}
+	dst := S{A: src.B, P: &intoInner{}, N: inner.In.N[1:]}
+	if err := CopyInto(&dst, src); err != nil {
+		t.Fatal(err)
+	}
+	expected := S{A: map[int]int{1: 1}, B: map[int]int{2: 2}, P: &intoInner{N: []int{1, 2}}, N: []int{3}}
+	if !cmp.Equal(src, expected) || !cmp.Equal(dst, expected) {
+		t.Fatalf("got source: %v, destination: %v, expected both to be %v", src, dst, expected)
+	}
+	if refs := Shared(dst, src); len(refs) != 0 {
+		t.Fatalf("got shared: %v, expected the copy to be independent", refs)
+	}
+}
+
+func TestCopyIntoUnexportedFunc(t *testing.T) {
+	type S struct {
+		A int
+		f func()
+	}
+	var dst S
+	if err := CopyInto(&dst, S{A: 1, f: func() {}}); err != nil || dst.A != 1 || dst.f != nil {
+		t.Fatalf("got: %+v, error: %v, expected the exported field to be copied", dst, err)
+	}
+}
+
+func TestCopyIntoErrors(t *testing.T) {
+	var x intoT
+	if err := CopyInto(x, intoT{}); err == nil {
+		t.Fatal("expected an error for a non pointer destination")
+	}
+	if err := CopyInto(&x, 1); err == nil {
+		t.Fatal("expected an error for a source of another type")
+	}
+	x.Items = []*intoInner{{}}
+	if err := CopyInto(&x, nil); err != nil || x.Items != nil {
+		t.Fatalf("expected a nil source to zero the destination, got %v", err)
+	}
+}
+
+func BenchmarkCopyInto(b *testing.B) {
+	type item struct {
+		Name   string
+		Values []int
+		Attrs  map[string]string
+		Parent *intoInner
+	}
+	type snapshot struct {
+		Items []item
+		Index map[string]*intoInner
+	}
+	src := snapshot{Index: make(map[string]*intoInner)}
+	for i := 0; i < 1000; i++ {
+		name := strconv.Itoa(i)
+		inner := &intoInner{N: []int{i}}
+		src.Items = append(src.Items, item{Name: name, Values: []int{i, i}, Attrs: map[string]string{"k": name}, Parent: inner})
+		src.Index[name] = inner
+	}
+	b.Run("Copy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Copy(src); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("CopyInto", func(b *testing.B) {
+		b.ReportAllocs()
+		dst := MustClone(src)
+		for i := 0; i < b.N; i++ {
+			if err := CopyInto(&dst, src); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
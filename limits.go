@@ -0,0 +1,54 @@
+package deepcopy
+
+import "sync/atomic"
+
+// Limits bounds the work of one copy, so a huge or deeply nested value cannot exhaust the memory or the stack.
+// A copy crossing a limit fails with a *LimitError. A zero limit means no limit.
+type Limits struct {
+	// MaxDepth is the maximum nesting of the copied values, counting the values that hold references,
+	// like structs, pointers, slices, maps and interfaces.
+	MaxDepth int
+	// MaxNodes is the maximum number of copied values that hold references.
+	// Values holding no references, like the elements of a []int, are not counted.
+	MaxNodes int
+	// MaxBytes is the maximum size, in bytes, of the pointed values, slices, maps and channels the copy allocates.
+	// Map sizes are estimated from the sizes of their keys and elements.
+	// Memory allocated by registered copiers and copy methods is not counted, and neither are strings, which are shared.
+	MaxBytes int
+}
+
+// WithLimits sets limits to the copy.
+func WithLimits(l Limits) Option {
+	return func(o *options) {
+		o.limits = l
+	}
+}
+
+// enter accounts for a value holding references about to be copied, one level deeper than the values being copied.
+// It must be followed by a call to leave, once the value is copied.
+func (c *copier) enter() error {
+	c.depth++
+	nodes := atomic.AddInt64(&c.nodes, 1)
+	switch l := &c.opts.limits; {
+	case l.MaxDepth > 0 && c.depth > l.MaxDepth:
+		return &LimitError{Limit: "depth", Max: l.MaxDepth, Path: c.path.String()}
+	case l.MaxNodes > 0 && nodes > int64(l.MaxNodes):
+		return &LimitError{Limit: "nodes", Max: l.MaxNodes, Path: c.path.String()}
+	}
+	return nil
+}
+
+func (c *copier) leave() {
+	c.depth--
+}
+
+// alloc accounts for size bytes about to be allocated by the copy.
+func (c *copier) alloc(size uintptr) error {
+	if c.opts.limits.MaxBytes <= 0 {
+		return nil
+	}
+	if atomic.AddUint64(&c.bytes, uint64(size)) > uint64(c.opts.limits.MaxBytes) {
+		return &LimitError{Limit: "bytes", Max: c.opts.limits.MaxBytes, Path: c.path.String()}
+	}
+	return nil
+}
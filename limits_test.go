@@ -0,0 +1,52 @@
+package deepcopy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCopyLimits(t *testing.T) {
+	type list struct {
+		Value int
+		Next  *list
+	}
+	var l *list
+	for i := 0; i < 10; i++ {
+		l = &list{Value: i, Next: l}
+	}
+	tests := []struct {
+		v      interface{}
+		limits Limits
+		err    *LimitError
+	}{
+		{l, Limits{MaxDepth: 5}, &LimitError{Limit: "depth", Max: 5, Path: ".Next.Next"}},
+		{l, Limits{MaxNodes: 7}, &LimitError{Limit: "nodes", Max: 7, Path: ".Next.Next.Next"}},
+		{l, Limits{MaxDepth: 21, MaxNodes: 21}, nil},
+		{make([]int64, 10, 100), Limits{MaxBytes: 799}, &LimitError{Limit: "bytes", Max: 799}},
+		{make([]int64, 10, 100), Limits{MaxBytes: 800}, nil},
+		{map[string][]byte{"a": make([]byte, 100)}, Limits{MaxBytes: 100}, &LimitError{Limit: "bytes", Max: 100, Path: `["a"]`}},
+		{map[string][]byte{"a": make([]byte, 100)}, Limits{MaxBytes: 1000}, nil},
+	}
+	for i, test := range tests {
+		_, err := Copy(test.v, WithLimits(test.limits))
+		if test.err == nil {
+			if err != nil {
+				t.Errorf("%d: got error: %v, expected nil", i, err)
+			}
+			continue
+		}
+		var lerr *LimitError
+		if !errors.As(err, &lerr) || *lerr != *test.err {
+			t.Errorf("%d: got error: %v, expected: %v", i, err, test.err)
+		}
+	}
+}
+
+func TestCopyIntoLimits(t *testing.T) {
+	src := [][]int{{1}, {2}, {3}}
+	dst := make([][]int, 3)
+	var lerr *LimitError
+	if err := CopyInto(&dst, src, WithLimits(Limits{MaxNodes: 3})); !errors.As(err, &lerr) || lerr.Path != "[2]" {
+		t.Fatalf("got error: %v, expected a *LimitError at [2]", err)
+	}
+}
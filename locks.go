@@ -0,0 +1,103 @@
+package deepcopy
+
+import (
+	"reflect"
+	"sync"
+)
+
+var (
+	lockerType  = reflect.TypeOf((*sync.Locker)(nil)).Elem()
+	rlockerType = reflect.TypeOf((*rlocker)(nil)).Elem()
+)
+
+// rlocker is implemented by read-write locks, like sync.RWMutex.
+type rlocker interface {
+	RLock()
+	RUnlock()
+}
+
+// lockerKind says how the values of a type are locked by LockSource.
+type lockerKind int
+
+const (
+	noLocker lockerKind = iota
+	// valueLocker is a type implementing sync.Locker.
+	valueLocker
+	// pointerLocker is a type whose pointer implements sync.Locker.
+	pointerLocker
+)
+
+// findLocker returns how the values of type t are locked by LockSource.
+// Locks themselves are reset rather than copied, so they are not locked,
+// and neither are pointers and interfaces, which are locked through the values they point to.
+func findLocker(t reflect.Type) lockerKind {
+	switch {
+	case t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface || isLock(t):
+		return noLocker
+	case t.Implements(lockerType):
+		return valueLocker
+	case reflect.PointerTo(t).Implements(lockerType):
+		return pointerLocker
+	}
+	return noLocker
+}
+
+// lock locks ov as LockSource says and returns the function unlocking it, or nil if ov cannot be locked.
+// A value whose pointer implements sync.Locker is locked only if it is addressable,
+// otherwise it is already a copy nobody else uses.
+// Read-write locks are read locked.
+func lock(ov reflect.Value, kind lockerKind) func() {
+	if kind == pointerLocker {
+		if !ov.CanAddr() {
+			return nil
+		}
+		ov = ov.Addr()
+	}
+	if ov.Type().Implements(rlockerType) {
+		l := ov.Interface().(rlocker)
+		l.RLock()
+		return l.RUnlock
+	}
+	l := ov.Interface().(sync.Locker)
+	l.Lock()
+	return l.Unlock
+}
+
+// syncLocks are the names of the lock types of package sync.
+// The other types of the package are not locks, so the entries of a sync.Map are copied like the ones of a map.
+var syncLocks = map[string]bool{"Mutex": true, "RWMutex": true, "WaitGroup": true, "Once": true, "Cond": true}
+
+// isLock reports whether values of type t are locks, which must not be copied.
+// Locks are the lock types of package sync, like sync.Mutex, sync.WaitGroup or sync.Once,
+// and the types whose pointer implements sync.Locker while they do not, and which hold nothing else:
+// zero size markers, like the noCopy types go vet knows about, and structs whose fields are all locks.
+// A type implementing sync.Locker itself, like *sync.Mutex, is a reference to a lock and not a lock.
+// A struct holding data besides its locks is not a lock either, it is copied with its lock fields reset.
+func isLock(t reflect.Type) bool {
+	switch {
+	case t.Kind() == reflect.Interface:
+		return false
+	case t.PkgPath() == "sync":
+		return syncLocks[t.Name()]
+	case !reflect.PointerTo(t).Implements(lockerType) || t.Implements(lockerType):
+		return false
+	case t.Size() == 0:
+		return true
+	case t.Kind() != reflect.Struct || t.NumField() == 0:
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if !isLock(t.Field(i).Type) {
+			return false
+		}
+	}
+	return true
+}
+
+// copyLock returns the copy of the lock ov, as WithSync says.
+func (c *copier) copyLock(ov reflect.Value) (reflect.Value, error) {
+	if c.opts.locks == SyncReject {
+		return reflect.Value{}, &ValueError{Type: ov.Type(), Path: c.path.String(), Reason: "a lock cannot be copied"}
+	}
+	return reflect.Zero(ov.Type()), nil
+}
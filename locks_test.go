@@ -0,0 +1,130 @@
+package deepcopy
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// noCopy is the marker go vet uses to warn about copied values.
+type noCopy struct{}
+
+func (*noCopy) Lock()   {}
+func (*noCopy) Unlock() {}
+
+type guarded struct {
+	sync.Mutex
+	Data   []int
+	Mu     sync.Mutex
+	RW     *sync.RWMutex
+	Tagged sync.Mutex `deepcopy:"shallow"`
+	wg     sync.WaitGroup
+	_      noCopy
+}
+
+// store guards its data with its own Lock and Unlock methods, so it is not a lock itself.
+type store struct {
+	mu   sync.Mutex
+	Data map[string]int
+}
+
+func (s *store) Lock()   { s.mu.Lock() }
+func (s *store) Unlock() { s.mu.Unlock() }
+
+func TestIsLock(t *testing.T) {
+	locks := []reflect.Type{
+		reflect.TypeOf(sync.Mutex{}), reflect.TypeOf(sync.RWMutex{}), reflect.TypeOf(sync.WaitGroup{}),
+		reflect.TypeOf(sync.Once{}), reflect.TypeOf(sync.Cond{}), reflect.TypeOf(noCopy{}),
+	}
+	for _, tp := range locks {
+		if !isLock(tp) {
+			t.Errorf("expected %s to be a lock", tp)
+		}
+	}
+	for _, tp := range []reflect.Type{
+		reflect.TypeOf(&sync.Mutex{}), reflect.TypeOf(guarded{}), lockerType,
+		reflect.TypeOf(store{}), reflect.TypeOf(sync.Map{}), reflect.TypeOf(sync.Pool{}),
+	} {
+		if isLock(tp) {
+			t.Errorf("expected %s not to be a lock", tp)
+		}
+	}
+}
+
+func TestCopyLocks(t *testing.T) {
+	g := &guarded{Data: []int{1}, RW: new(sync.RWMutex)}
+	g.Lock()
+	g.Mu.Lock()
+	g.RW.Lock()
+	g.Tagged.Lock()
+	g.wg.Add(1)
+	for _, mode := range []UnexportedMode{UnexportedZero, UnexportedShallow, UnexportedDeep} {
+		v, err := Clone(g, WithUnexported(mode))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !v.TryLock() || !v.Mu.TryLock() || !v.Tagged.TryLock() || v.RW == g.RW || !v.RW.TryLock() {
+			t.Fatalf("mode %d: expected the locks to be reset", mode)
+		}
+		// a locked wait group would make Wait block
+		v.wg.Wait()
+		if len(v.Data) != 1 || &v.Data[0] == &g.Data[0] {
+			t.Fatalf("mode %d: expected the struct embedding a lock to be copied", mode)
+		}
+	}
+	dst := new(guarded)
+	dst.Mu.Lock()
+	if err := CopyInto(&dst, g, WithUnexported(UnexportedDeep)); err != nil {
+		t.Fatal(err)
+	}
+	if !dst.Mu.TryLock() {
+		t.Fatal("expected the lock of the destination to be reset")
+	}
+}
+
+func TestCopyLocksReject(t *testing.T) {
+	var verr *ValueError
+	if _, err := Clone(&guarded{}, WithSync(SyncReject)); !errors.As(err, &verr) || verr.Path != ".Mutex" {
+		t.Fatalf("got error: %v, expected a *ValueError at .Mutex", err)
+	}
+	type unexported struct {
+		mu sync.Mutex
+	}
+	if _, err := Clone(&unexported{}, WithSync(SyncReject)); err != nil {
+		t.Fatalf("got error: %v, expected the unexported lock to be left zero", err)
+	}
+	if _, err := Clone(&unexported{}, WithSync(SyncReject), WithUnexported(UnexportedDeep)); !errors.As(err, &verr) || verr.Path != ".mu" {
+		t.Fatalf("got error: %v, expected a *ValueError at .mu", err)
+	}
+}
+
+func TestCopyLockerWithData(t *testing.T) {
+	s := &store{Data: map[string]int{"a": 1}}
+	s.mu.Lock()
+	for _, opts := range [][]Option{nil, {WithSync(SyncReject)}, {WithUnexported(UnexportedDeep)}} {
+		v, err := Clone(s, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Data["a"] != 1 || !v.mu.TryLock() {
+			t.Fatalf("got: %+v, expected the data to be copied and the lock to be reset", v)
+		}
+	}
+	if _, err := Clone(s, WithSync(SyncReject), WithUnexported(UnexportedDeep)); err == nil {
+		t.Fatal("expected the lock field to be rejected")
+	}
+
+	type cache struct {
+		m sync.Map
+	}
+	c := &cache{}
+	c.m.Store("a", 1)
+	v, err := Clone(c, WithUnexported(UnexportedDeep))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := v.m.Load("a"); !ok || n != 1 {
+		t.Fatal("expected the entries of sync.Map to be copied")
+	}
+}
@@ -0,0 +1,346 @@
+package deepcopy
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// MergeOption configures a single call to Merge.
+type MergeOption func(*mergeOptions)
+
+// mergeOptions holds the configuration of one merge.
+type mergeOptions struct {
+	slices SliceStrategy
+	key    string
+	zero   bool
+	copy   []Option
+}
+
+// SliceStrategy says how Merge merges a slice into another.
+type SliceStrategy int
+
+const (
+	// SliceReplace replaces the destination slice with a copy of the source one. This is the default.
+	SliceReplace SliceStrategy = iota
+	// SliceAppend appends copies of the source elements to the destination slice.
+	SliceAppend
+	// SliceByIndex merges every source element into the destination element with the same index,
+	// and appends copies of the source elements past the end of the destination slice.
+	SliceByIndex
+	// sliceByKey merges every source element into the destination element with the same key, set by MergeSlicesByKey.
+	sliceByKey
+)
+
+// MergeSlices sets how slices are merged.
+func MergeSlices(s SliceStrategy) MergeOption {
+	return func(o *mergeOptions) {
+		o.slices = s
+	}
+}
+
+// MergeSlicesByKey merges slices of structs, or of pointers to structs, by the value of their field with the name.
+// Every source element is merged into the destination element with the same key,
+// or appended to the destination slice if there is none.
+// Merging a slice whose elements have no such comparable field fails.
+func MergeSlicesByKey(field string) MergeOption {
+	return func(o *mergeOptions) {
+		o.slices, o.key = sliceByKey, field
+	}
+}
+
+// MergeZero makes zero source values overwrite the destination values, which they leave as they are by default.
+func MergeZero() MergeOption {
+	return func(o *mergeOptions) {
+		o.zero = true
+	}
+}
+
+// MergeCopyOptions sets the options of the copies of the source values.
+func MergeCopyOptions(opts ...Option) MergeOption {
+	return func(o *mergeOptions) {
+		o.copy = opts
+	}
+}
+
+// Merge deep merges src into the value dst points to.
+// Structs are merged field by field, following the deepcopy tags and the unexported mode of the copy options,
+// and maps are merged key by key, so dst keeps the keys src does not have.
+// Slices are merged as set by MergeSlices, pointers and interfaces holding the same type are merged into what they hold,
+// and zero source values are skipped unless MergeZero is used. Locks are left as they are.
+// Everything else, and the values of types with a copier or a copy method, is replaced with a deep copy of the source value,
+// so dst never references the memory of src.
+// dst must be a non nil pointer and src must be assignable to the type dst points to, or a pointer to that type.
+func Merge(dst, src interface{}, opts ...MergeOption) error {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return fmt.Errorf("deepcopy: Merge destination must be a non nil pointer, not %T", dst)
+	}
+	dv = dv.Elem()
+	sv := reflect.ValueOf(src)
+	if !sv.IsValid() {
+		return nil
+	}
+	if sv.Kind() == reflect.Ptr && sv.Type().Elem() == dv.Type() {
+		if sv.IsNil() {
+			return nil
+		}
+		sv = sv.Elem()
+	}
+	if !sv.Type().AssignableTo(dv.Type()) {
+		return fmt.Errorf("deepcopy: Merge cannot merge %s into %s", sv.Type(), dv.Type())
+	}
+	var o mergeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	m := &merger{copier: newCopier(o.copy), mopts: o, merged: make(map[[2]visit]bool)}
+	if sv.Type() != dv.Type() {
+		return m.replace(dv, sv, planFor(sv.Type()))
+	}
+	return m.merge(dv, sv, planFor(sv.Type()))
+}
+
+// merger holds the state of one Merge.
+// The copier makes the copies of the source values, so they share memory with one another as the source values do.
+type merger struct {
+	*copier
+	mopts mergeOptions
+	// merged holds the pairs of source and destination pointers already merged, so cycles end.
+	// A source pointer is merged into every destination pointer it is found with.
+	merged map[[2]visit]bool
+}
+
+// merge merges sv into the addressable value dv of the same type, following the plan p.
+func (m *merger) merge(dv, sv reflect.Value, p *plan) error {
+	if !m.mopts.zero && sv.IsZero() {
+		return nil
+	}
+	if p.lock {
+		return nil
+	}
+	if p.copier != nil || p.method.kind != noMethod && !m.opts.ignoreMethods || p.sensitive && m.opts.redact {
+		return m.replace(dv, sv, p)
+	}
+	switch p.strategy {
+	case structStrategy:
+		return m.mergeFields(dv, sv, p)
+	case pointerStrategy:
+		if dv.IsNil() || sv.IsNil() {
+			return m.replace(dv, sv, p)
+		}
+		key := [2]visit{{ptr: sv.Pointer(), typ: p.typ}, {ptr: dv.Pointer(), typ: p.typ}}
+		if m.merged[key] {
+			return nil
+		}
+		m.merged[key] = true
+		return m.merge(dv.Elem(), sv.Elem(), p.elem)
+	case interfaceStrategy:
+		if dv.IsNil() || sv.IsNil() || dv.Elem().Type() != sv.Elem().Type() {
+			return m.replace(dv, sv, p)
+		}
+		ev := reflect.New(sv.Elem().Type()).Elem()
+		ev.Set(dv.Elem())
+		if err := m.merge(ev, sv.Elem(), planFor(ev.Type())); err != nil {
+			return err
+		}
+		dv.Set(ev)
+		return nil
+	case mapStrategy:
+		if dv.IsNil() || sv.IsNil() {
+			return m.replace(dv, sv, p)
+		}
+		return m.mergeMap(dv, sv, p)
+	case sliceStrategy:
+		if dv.IsNil() || sv.IsNil() {
+			return m.replace(dv, sv, p)
+		}
+		return m.mergeSlice(dv, sv, p)
+	case arrayStrategy:
+		return m.mergeElems(dv, sv, p, sv.Len())
+	}
+	return m.replace(dv, sv, p)
+}
+
+// replace sets dv to a deep copy of sv.
+func (m *merger) replace(dv, sv reflect.Value, p *plan) error {
+	oc, err := m.copyPlan(sv, p)
+	if err != nil {
+		return err
+	}
+	dv.Set(oc)
+	return nil
+}
+
+func (m *merger) mergeFields(dv, sv reflect.Value, p *plan) error {
+	for i := range p.fields {
+		f := &p.fields[i]
+		fv, dst := sv.Field(f.index), dv.Field(f.index)
+		mode := m.fieldMode(f, fv)
+		if mode == tagSkip || !m.mopts.zero && fv.IsZero() {
+			continue
+		}
+		// runtime does not allow setting unexported fields, so we go around it
+		if !f.exported {
+			if !sv.CanAddr() {
+				sv = addressable(sv)
+				fv = sv.Field(f.index)
+			}
+			fv, dst = exposed(fv), exposed(dst)
+		}
+		m.path.push(PathStep{Kind: FieldStep, Name: f.name})
+		fc, tagged, err := m.copyTagged(fv, f.plan, mode)
+		switch {
+		case !tagged:
+			err = m.merge(dst, fv, f.plan)
+		case err == nil:
+			dst.Set(fc)
+		}
+		m.path.pop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *merger) mergeMap(dv, sv reflect.Value, p *plan) error {
+	iter := sv.MapRange()
+	for iter.Next() {
+		k, v := iter.Key(), iter.Value()
+		m.path.push(PathStep{Kind: KeyStep, Key: k})
+		err := m.mergeEntry(dv, k, v, p)
+		m.path.pop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeEntry merges the entry of a source map into the destination map dv.
+func (m *merger) mergeEntry(dv, k, v reflect.Value, p *plan) error {
+	redacted := m.redacted
+	kc, err := m.copyPlan(k, p.key)
+	if err != nil || m.redacted != redacted {
+		// the entry of a redacted key is left out, like in a copy
+		return err
+	}
+	ev := reflect.New(p.elem.typ).Elem()
+	if old := dv.MapIndex(k); old.IsValid() {
+		ev.Set(old)
+		err = m.merge(ev, v, p.elem)
+	} else {
+		err = m.replace(ev, v, p.elem)
+	}
+	if err != nil {
+		return err
+	}
+	dv.SetMapIndex(kc, ev)
+	return nil
+}
+
+func (m *merger) mergeSlice(dv, sv reflect.Value, p *plan) error {
+	switch m.mopts.slices {
+	case SliceAppend:
+		oc, err := m.copyPlan(sv, p)
+		if err != nil {
+			return err
+		}
+		dv.Set(reflect.AppendSlice(dv, oc))
+		return nil
+	case SliceByIndex:
+		return m.mergeElems(dv, sv, p, min(dv.Len(), sv.Len()))
+	case sliceByKey:
+		return m.mergeByKey(dv, sv, p)
+	}
+	return m.replace(dv, sv, p)
+}
+
+// mergeElems merges the first n elements of sv into the ones of dv, and appends copies of the rest of them to dv.
+func (m *merger) mergeElems(dv, sv reflect.Value, p *plan, n int) error {
+	for i := 0; i < sv.Len(); i++ {
+		m.path.push(PathStep{Kind: IndexStep, Index: i})
+		var err error
+		if i < n {
+			err = m.merge(dv.Index(i), sv.Index(i), p.elem)
+		} else {
+			err = m.appendCopy(dv, sv.Index(i), p.elem)
+		}
+		m.path.pop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *merger) mergeByKey(dv, sv reflect.Value, p *plan) error {
+	keys := make(map[interface{}]int, dv.Len())
+	for i := 0; i < dv.Len(); i++ {
+		k, ok, err := m.elemKey(dv.Index(i))
+		if err != nil {
+			return err
+		}
+		if ok {
+			keys[k] = i
+		}
+	}
+	for i := 0; i < sv.Len(); i++ {
+		k, ok, err := m.elemKey(sv.Index(i))
+		if err != nil {
+			return err
+		}
+		m.path.push(PathStep{Kind: IndexStep, Index: i})
+		if j, found := keys[k]; ok && found {
+			err = m.merge(dv.Index(j), sv.Index(i), p.elem)
+		} else if !m.mopts.zero && sv.Index(i).IsZero() {
+			err = nil
+		} else {
+			err = m.appendCopy(dv, sv.Index(i), p.elem)
+		}
+		m.path.pop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// elemKey returns the key of the slice element v, which is a struct or a pointer to a struct,
+// and reports whether it has one, as a nil pointer does not.
+func (m *merger) elemKey(v reflect.Value) (interface{}, bool, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false, nil
+		}
+		v = v.Elem()
+	}
+	var f reflect.StructField
+	ok := v.Kind() == reflect.Struct
+	if ok {
+		f, ok = v.Type().FieldByName(m.mopts.key)
+	}
+	if !ok || !f.IsExported() || !f.Type.Comparable() {
+		return nil, false, fmt.Errorf("deepcopy: Merge cannot merge %s by key, it has no comparable exported field %s", v.Type(), m.mopts.key)
+	}
+	// a promoted field behind a nil embedded pointer is no key either
+	k, err := v.FieldByIndexErr(f.Index)
+	if err != nil {
+		return nil, false, nil
+	}
+	// an interface is comparable, but not the slice or map it may hold
+	if !k.Comparable() {
+		return nil, false, fmt.Errorf("deepcopy: Merge cannot merge %s by key, its field %s holds an incomparable %s", v.Type(), m.mopts.key, k.Elem().Type())
+	}
+	return k.Interface(), true, nil
+}
+
+// appendCopy appends a copy of the element ev to the slice dv.
+func (m *merger) appendCopy(dv, ev reflect.Value, p *plan) error {
+	oc, err := m.copyPlan(ev, p)
+	if err != nil {
+		return err
+	}
+	dv.Set(reflect.Append(dv, oc))
+	return nil
+}
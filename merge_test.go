@@ -0,0 +1,184 @@
+package deepcopy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type layer struct {
+	Name     string
+	Port     int
+	Debug    bool
+	Timeout  time.Duration
+	Started  time.Time
+	Hosts    []string
+	Backends []*backend
+	Labels   map[string]string
+	Limits   map[string]*backend
+	Parent   *layer
+	Extra    interface{}
+	Secret   string `deepcopy:"-"`
+	local    int
+}
+
+type backend struct {
+	Name    string
+	Weight  int
+	Servers []string
+}
+
+func TestMerge(t *testing.T) {
+	started := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defaults := func() *layer {
+		return &layer{
+			Name:     "defaults",
+			Port:     80,
+			Timeout:  time.Second,
+			Hosts:    []string{"a", "b"},
+			Backends: []*backend{{Name: "x", Weight: 1}, {Name: "y", Weight: 1}},
+			Labels:   map[string]string{"env": "dev", "team": "core"},
+			Limits:   map[string]*backend{"x": {Name: "x", Weight: 1, Servers: []string{"s"}}},
+			Extra:    map[string]int{"a": 1},
+			Secret:   "kept",
+			local:    1,
+		}
+	}
+	override := &layer{
+		Port:     8080,
+		Started:  started,
+		Hosts:    []string{"c"},
+		Backends: []*backend{{Name: "y", Weight: 2}, {Name: "z", Weight: 3}},
+		Labels:   map[string]string{"env": "prod"},
+		Limits:   map[string]*backend{"x": {Weight: 5}, "y": {Name: "y"}},
+		Parent:   &layer{Name: "parent"},
+		Extra:    map[string]int{"b": 2},
+		Secret:   "ignored",
+		local:    2,
+	}
+	tests := []struct {
+		name     string
+		opts     []MergeOption
+		hosts    []string
+		backends []*backend
+	}{
+		{
+			name:     "replace",
+			hosts:    []string{"c"},
+			backends: []*backend{{Name: "y", Weight: 2}, {Name: "z", Weight: 3}},
+		},
+		{
+			name:     "append",
+			opts:     []MergeOption{MergeSlices(SliceAppend)},
+			hosts:    []string{"a", "b", "c"},
+			backends: []*backend{{Name: "x", Weight: 1}, {Name: "y", Weight: 1}, {Name: "y", Weight: 2}, {Name: "z", Weight: 3}},
+		},
+		{
+			name:     "index",
+			opts:     []MergeOption{MergeSlices(SliceByIndex)},
+			hosts:    []string{"c", "b"},
+			backends: []*backend{{Name: "y", Weight: 2}, {Name: "z", Weight: 3}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dst := defaults()
+			if err := Merge(dst, override, test.opts...); err != nil {
+				t.Fatal(err)
+			}
+			expected := &layer{
+				Name:     "defaults",
+				Port:     8080,
+				Timeout:  time.Second,
+				Started:  started,
+				Hosts:    test.hosts,
+				Backends: test.backends,
+				Labels:   map[string]string{"env": "prod", "team": "core"},
+				Limits:   map[string]*backend{"x": {Name: "x", Weight: 5, Servers: []string{"s"}}, "y": {Name: "y"}},
+				Parent:   &layer{Name: "parent"},
+				Extra:    map[string]int{"a": 1, "b": 2},
+				Secret:   "kept",
+				local:    1,
+			}
+			if !reflect.DeepEqual(dst, expected) {
+				t.Fatalf("got: %+v, expected: %+v", dst, expected)
+			}
+			if refs := Shared(dst, override); len(refs) != 0 {
+				t.Fatalf("got shared: %v, expected the merged value not to share memory with the source", refs)
+			}
+		})
+	}
+
+	backends := []*backend{{Name: "x", Weight: 1}, nil, {Name: "y", Weight: 1, Servers: []string{"s"}}}
+	if err := Merge(&backends, []*backend{{Name: "y", Weight: 2}, {Name: "z", Weight: 3}}, MergeSlicesByKey("Name")); err != nil {
+		t.Fatal(err)
+	}
+	expected := []*backend{{Name: "x", Weight: 1}, nil, {Name: "y", Weight: 2, Servers: []string{"s"}}, {Name: "z", Weight: 3}}
+	if !reflect.DeepEqual(backends, expected) {
+		t.Fatalf("got: %+v, expected: %+v", backends, expected)
+	}
+	dst := defaults()
+	if err := Merge(dst, override, MergeSlicesByKey("Name")); err == nil || !strings.Contains(err.Error(), "by key") {
+		t.Fatalf("got error: %v, expected strings not to be merged by key", err)
+	}
+	type tagged struct{ Key interface{} }
+	err := Merge(&[]tagged{{Key: 1}}, []tagged{{Key: []int{1}}}, MergeSlicesByKey("Key"))
+	if err == nil || !strings.Contains(err.Error(), "incomparable") {
+		t.Fatalf("got error: %v, expected an interface holding a slice not to be a key", err)
+	}
+
+	dst = defaults()
+	if err := Merge(dst, layer{Name: "zero"}, MergeZero()); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Name != "zero" || dst.Port != 0 || dst.Hosts != nil || dst.Labels != nil || dst.Secret != "kept" {
+		t.Fatalf("got: %+v, expected the zero values to overwrite the destination", dst)
+	}
+}
+
+func TestMergeCycle(t *testing.T) {
+	src := &layer{Name: "src"}
+	src.Parent = src
+	dst := &layer{Port: 1}
+	dst.Parent = dst
+	if err := Merge(&dst, src); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Name != "src" || dst.Port != 1 || dst.Parent != dst {
+		t.Fatalf("got: %+v, expected the cycle to be merged into", dst)
+	}
+	if err := Merge(dst, 1); err == nil {
+		t.Fatal("expected an error merging a different type")
+	}
+
+	// a source pointer found twice is merged into both destinations
+	type point struct{ V, W int }
+	type pair struct{ A, B *point }
+	p := &point{V: 1}
+	to := pair{A: &point{W: 5}, B: &point{W: 6}}
+	if err := Merge(&to, pair{A: p, B: p}); err != nil {
+		t.Fatal(err)
+	}
+	if *to.A != (point{V: 1, W: 5}) || *to.B != (point{V: 1, W: 6}) {
+		t.Fatalf("got: %+v and %+v, expected the source to be merged into both", to.A, to.B)
+	}
+}
+
+// TestMergeZeroUnsupported checks a zero unsupported field is left as it is, like Copy leaves it zero.
+func TestMergeZeroUnsupported(t *testing.T) {
+	type handle struct {
+		Name string
+		P    uintptr
+	}
+	dst := handle{Name: "a"}
+	if err := Merge(&dst, handle{}, MergeZero()); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Name != "" {
+		t.Fatalf("got: %+v, expected the zero name to overwrite the destination", dst)
+	}
+	if err := Merge(&dst, handle{P: 1}); err == nil {
+		t.Fatal("expected an error merging an unsupported value")
+	}
+}
@@ -0,0 +1,114 @@
+package deepcopy
+
+import (
+	"reflect"
+)
+
+// methodKind says how a type copies itself.
+type methodKind int
+
+const (
+	noMethod methodKind = iota
+	// valueMethod is a DeepCopy() T or Clone() T method.
+	valueMethod
+	// pointerMethod is a DeepCopy() *T or Clone() *T method of *T, for a non pointer T.
+	pointerMethod
+	// intoMethod is a DeepCopyInto(*T) method of *T, for a non pointer T.
+	intoMethod
+	// pointerIntoMethod is a DeepCopyInto(T) method of T, for a pointer T.
+	pointerIntoMethod
+)
+
+// copyMethod is the method a type copies itself with.
+type copyMethod struct {
+	kind methodKind
+	// index is the index of the method in the method set of its receiver.
+	index int
+}
+
+// findCopyMethod returns the method values of type t copy themselves with.
+func findCopyMethod(t reflect.Type) copyMethod {
+	if t.Kind() == reflect.Interface {
+		return copyMethod{}
+	}
+	if m, ok := t.MethodByName("DeepCopy"); ok && returnsOwnType(m, t) {
+		return copyMethod{kind: valueMethod, index: m.Index}
+	}
+	if t.Kind() == reflect.Ptr {
+		if m, ok := t.MethodByName("DeepCopyInto"); ok && copiesInto(m, t) {
+			return copyMethod{kind: pointerIntoMethod, index: m.Index}
+		}
+		if m, ok := t.MethodByName("Clone"); ok && returnsOwnType(m, t) {
+			return copyMethod{kind: valueMethod, index: m.Index}
+		}
+		return copyMethod{}
+	}
+	pt := reflect.PointerTo(t)
+	if m, ok := pt.MethodByName("DeepCopy"); ok && returnsOwnType(m, pt) {
+		return copyMethod{kind: pointerMethod, index: m.Index}
+	}
+	if m, ok := pt.MethodByName("DeepCopyInto"); ok && copiesInto(m, pt) {
+		return copyMethod{kind: intoMethod, index: m.Index}
+	}
+	if m, ok := t.MethodByName("Clone"); ok && returnsOwnType(m, t) {
+		return copyMethod{kind: valueMethod, index: m.Index}
+	}
+	if m, ok := pt.MethodByName("Clone"); ok && returnsOwnType(m, pt) {
+		return copyMethod{kind: pointerMethod, index: m.Index}
+	}
+	return copyMethod{}
+}
+
+// returnsOwnType reports whether the method m of t has no arguments and returns a t.
+func returnsOwnType(m reflect.Method, t reflect.Type) bool {
+	return m.Type.NumIn() == 1 && m.Type.NumOut() == 1 && m.Type.Out(0) == t
+}
+
+// copiesInto reports whether the method m of the pointer type t takes a t and returns nothing.
+func copiesInto(m reflect.Method, t reflect.Type) bool {
+	return m.Type.NumIn() == 2 && m.Type.In(1) == t && m.Type.NumOut() == 0
+}
+
+// copyWithMethod copies ov by calling its copy method m.
+func (c *copier) copyWithMethod(ov reflect.Value, m copyMethod) (reflect.Value, error) {
+	var key visit
+	if ov.Kind() == reflect.Ptr || ov.Kind() == reflect.Map || ov.Kind() == reflect.Slice {
+		if ov.IsNil() {
+			return ov, nil
+		}
+		if ov.Kind() != reflect.Slice {
+			key = visit{ptr: ov.Pointer(), typ: ov.Type()}
+			if oc, ok := c.seen(key); ok {
+				return oc, nil
+			}
+		}
+	}
+	var oc reflect.Value
+	switch m.kind {
+	case valueMethod:
+		oc = ov.Method(m.index).Call(nil)[0]
+	case pointerMethod:
+		if !ov.CanAddr() {
+			ov = addressable(ov)
+		}
+		oc = ov.Addr().Method(m.index).Call(nil)[0]
+		if oc.IsNil() {
+			return reflect.Zero(ov.Type()), nil
+		}
+		oc = oc.Elem()
+	case intoMethod:
+		if !ov.CanAddr() {
+			ov = addressable(ov)
+		}
+		oc = reflect.New(ov.Type())
+		ov.Addr().Method(m.index).Call([]reflect.Value{oc})
+		oc = oc.Elem()
+	case pointerIntoMethod:
+		oc = reflect.New(ov.Type().Elem())
+		ov.Method(m.index).Call([]reflect.Value{oc})
+	}
+	if key.typ != nil {
+		oc, _ = c.register(key, oc)
+	}
+	return oc, nil
+}
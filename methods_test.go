@@ -0,0 +1,87 @@
+package deepcopy
+
+import (
+	"net/http"
+	"testing"
+)
+
+// cached is copied with DeepCopyInto, which rebuilds the cache instead of copying it.
+type cached struct {
+	Items []string
+	Cache map[string]int
+}
+
+func (in *cached) DeepCopyInto(out *cached) {
+	out.Items = append([]string(nil), in.Items...)
+	out.Cache = make(map[string]int, len(out.Items))
+	for i, item := range out.Items {
+		out.Cache[item] = i
+	}
+}
+
+// counted counts how many times it was copied.
+type counted struct {
+	N int
+}
+
+func (c *counted) DeepCopy() *counted {
+	return &counted{N: c.N + 1}
+}
+
+type cloned struct {
+	V int
+}
+
+func (c cloned) Clone() cloned {
+	return cloned{V: -c.V}
+}
+
+func TestCopyMethods(t *testing.T) {
+	type S struct {
+		C  cached
+		P  *counted
+		V  counted
+		L  []cloned
+		H  http.Header
+		NP *counted
+	}
+	u := S{
+		C: cached{Items: []string{"a", "b"}, Cache: map[string]int{"stale": 1}},
+		P: &counted{N: 1},
+		V: counted{N: 10},
+		L: []cloned{{V: 1}},
+		H: http.Header{"A": {"b"}},
+	}
+	vi, err := Copy(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := vi.(S)
+	if _, ok := v.C.Cache["stale"]; ok || v.C.Cache["b"] != 1 {
+		t.Fatalf("got cache: %v, expected it rebuilt by DeepCopyInto", v.C.Cache)
+	}
+	if v.P.N != 2 || v.V.N != 11 {
+		t.Fatalf("got: %d, %d, expected DeepCopy to be called", v.P.N, v.V.N)
+	}
+	if v.L[0].V != -1 {
+		t.Fatalf("got: %d, expected Clone to be called", v.L[0].V)
+	}
+	v.H.Add("A", "c")
+	if len(u.H["A"]) != 1 {
+		t.Fatal("header is shared with the original")
+	}
+	if v.NP != nil {
+		t.Fatalf("got: %v, expected nil", v.NP)
+	}
+}
+
+func TestCopyIgnoreMethods(t *testing.T) {
+	u := []counted{{N: 1}}
+	v, err := Clone(u, IgnoreMethods())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v[0].N != 1 {
+		t.Fatalf("got: %d, expected DeepCopy to be ignored", v[0].N)
+	}
+}
@@ -0,0 +1,141 @@
+package deepcopy
+
+// Option configures a single call to Copy or Clone.
+type Option func(*options)
+
+// options holds the configuration of one deep copy.
+type options struct {
+	unexported    UnexportedMode
+	ignoreMethods bool
+	chans         ChanMode
+	funcs         FuncMode
+	locks         SyncMode
+	lockSource    bool
+	limits        Limits
+	workers       int
+	minParallel   int
+	transform     TransformFunc
+	redact        bool
+	placeholder   string
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// UnexportedMode says how the unexported fields of a struct are copied.
+type UnexportedMode int
+
+const (
+	// UnexportedZero leaves unexported fields zero in the copy. This is the default.
+	UnexportedZero UnexportedMode = iota
+	// UnexportedShallow assigns unexported fields to the copy, so pointers, maps and slices are shared with the original.
+	UnexportedShallow
+	// UnexportedDeep deep copies unexported fields, just like exported ones.
+	UnexportedDeep
+)
+
+// WithUnexported sets how unexported fields of a struct are copied.
+// Unexported fields are read and written through package unsafe, as reflection does not allow it otherwise.
+func WithUnexported(mode UnexportedMode) Option {
+	return func(o *options) {
+		o.unexported = mode
+	}
+}
+
+// ChanMode says how channels are copied.
+type ChanMode int
+
+const (
+	// ChanShare assigns channels to the copy, so the copy sends to and receives from the original channels.
+	// This is the default.
+	ChanShare ChanMode = iota
+	// ChanNil leaves channels nil in the copy.
+	ChanNil
+	// ChanEmpty makes a new empty channel, with the same type and capacity as the original one.
+	ChanEmpty
+	// ChanFill makes a new channel, with the same type and capacity as the original one,
+	// holding copies of the elements buffered in the original.
+	// The buffered elements are received from the original channel and sent back in the same order,
+	// so the original must not be used concurrently with the copy and must not be closed.
+	ChanFill
+)
+
+// WithChan sets how channels are copied.
+// A channel shared in the original is shared in the copy as well, whatever the mode.
+func WithChan(mode ChanMode) Option {
+	return func(o *options) {
+		o.chans = mode
+	}
+}
+
+// FuncMode says how functions are copied.
+// Functions cannot be deep copied, as there is no way to copy the variables a closure captured.
+type FuncMode int
+
+const (
+	// FuncShare assigns functions to the copy, so closures share their captured variables with the original.
+	// This is the default.
+	FuncShare FuncMode = iota
+	// FuncNil leaves functions nil in the copy.
+	FuncNil
+	// FuncReject fails the copy with a *ValueError when it finds a function that is not nil.
+	FuncReject
+	// FuncStrict shares functions declared at package level and fails the copy with a *ValueError
+	// when it finds a closure: a function literal or a method value, which is bound to its receiver.
+	// A function literal is refused even if it captures no variables, as the runtime cannot tell them apart.
+	FuncStrict
+)
+
+// WithFunc sets how functions are copied.
+// A function field tagged with deepcopy:"shallow" is always shared.
+func WithFunc(mode FuncMode) Option {
+	return func(o *options) {
+		o.funcs = mode
+	}
+}
+
+// SyncMode says how locks are copied.
+// Locks are sync.Mutex, sync.RWMutex, sync.WaitGroup, sync.Once and sync.Cond,
+// and the types whose pointer implements sync.Locker and that hold nothing but locks, like the noCopy types go vet warns about.
+// A struct holding data besides its locks is copied with its lock fields reset.
+// Their state is never copied, whatever the tags, WithUnexported, registered copiers or copy methods say.
+type SyncMode int
+
+const (
+	// SyncReset leaves locks zero in the copy, so they are unlocked. This is the default.
+	SyncReset SyncMode = iota
+	// SyncReject fails the copy with a *ValueError when it finds a lock it would copy.
+	// Unexported lock fields left zero because of WithUnexported are not copied and do not fail the copy.
+	SyncReject
+)
+
+// WithSync sets how locks are copied.
+func WithSync(mode SyncMode) Option {
+	return func(o *options) {
+		o.locks = mode
+	}
+}
+
+// LockSource makes the copy lock the values implementing sync.Locker, or whose pointer does, while copying them,
+// like a struct embedding a sync.Mutex, so that data guarded by a lock can be copied without a data race.
+// Values with RLock and RUnlock methods, like those embedding a sync.RWMutex, are read locked.
+// The locks are the ones of the original values, the copies still get unlocked locks.
+// A value that locks one of the values it holds, or a lock it shares with them, deadlocks the copy.
+func LockSource() Option {
+	return func(o *options) {
+		o.lockSource = true
+	}
+}
+
+// IgnoreMethods makes the copy ignore DeepCopy, DeepCopyInto and Clone methods and copy all values by reflection.
+// A copy method that uses Copy or Clone on its own receiver must pass it, otherwise it calls itself forever.
+func IgnoreMethods() Option {
+	return func(o *options) {
+		o.ignoreMethods = true
+	}
+}
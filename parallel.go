@@ -0,0 +1,140 @@
+package deepcopy
+
+import (
+	"reflect"
+	"sync"
+)
+
+// WithParallel makes the copy split the slices and maps with at least minLen elements
+// across up to workers goroutines, counting the calling one, when their elements hold references.
+// Nested slices and maps are split as well, as long as the number of goroutines allows it.
+// The copy is the same as a sequential one, with shared references and cycles reproduced,
+// and the error returned is the one of the first failing chunk of elements.
+// Registered copiers and copy methods must be safe to call concurrently.
+// LockSource makes the copy sequential, as the locks it takes could deadlock the goroutines.
+func WithParallel(workers, minLen int) Option {
+	return func(o *options) {
+		o.workers = workers
+		o.minParallel = minLen
+	}
+}
+
+// parallel reports whether n elements are copied in parallel.
+func (c *copier) parallel(n int) bool {
+	return c.slots != nil && n >= c.opts.minParallel && n > 1
+}
+
+// fork returns a copier for another goroutine, copying the value c is copying.
+func (c *copier) fork() *copier {
+	return &copier{copyState: c.copyState, path: append(Path(nil), c.path...), depth: c.depth}
+}
+
+// chunks calls f for consecutive chunks of the n indexes, from inclusive, to exclusive.
+// Chunks run in their own goroutine when a goroutine slot is free, and on the calling goroutine otherwise.
+// It returns the error of the first failing chunk, and panics again with the panic of the first panicking one.
+func (c *copier) chunks(n int, f func(w *copier, from, to int) error) error {
+	workers := cap(c.slots) + 1
+	size := (n + workers - 1) / workers
+	count := (n + size - 1) / size
+	errs := make([]error, count)
+	panics := make([]interface{}, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		from, to := i*size, min((i+1)*size, n)
+		w := c.fork()
+		// the calling goroutine copies the last chunk, instead of waiting idle
+		if i < count-1 {
+			select {
+			case c.slots <- struct{}{}:
+				wg.Add(1)
+				go func(i int) {
+					defer func() {
+						panics[i] = recover()
+						<-c.slots
+						wg.Done()
+					}()
+					errs[i] = f(w, from, to)
+				}(i)
+				continue
+			default:
+			}
+		}
+		errs[i] = f(w, from, to)
+		if errs[i] != nil {
+			break
+		}
+	}
+	wg.Wait()
+	for i := range errs {
+		if panics[i] != nil {
+			panic(panics[i])
+		}
+		if errs[i] != nil {
+			return errs[i]
+		}
+	}
+	return nil
+}
+
+// copyElemsParallel is like copyElems, but copies chunks of elements in parallel.
+func (c *copier) copyElemsParallel(oc, ov reflect.Value, p *plan, from, to int) error {
+	return c.chunks(to-from, func(w *copier, first, last int) error {
+		for i := from + first; i < from+last; i++ {
+			w.path.push(PathStep{Kind: IndexStep, Index: i})
+			ec, err := w.copyPlan(ov.Index(i), p)
+			w.path.pop()
+			if err != nil {
+				return err
+			}
+			oc.Index(i).Set(ec)
+		}
+		return nil
+	})
+}
+
+// fillMapParallel is like fillMap, but copies chunks of entries in parallel.
+// The entries are set in oc once all are copied, as a map cannot be written concurrently.
+func (c *copier) fillMapParallel(oc, ov reflect.Value, p *plan) error {
+	keys := make([]reflect.Value, 0, ov.Len())
+	vals := make([]reflect.Value, 0, ov.Len())
+	iter := ov.MapRange()
+	for iter.Next() {
+		keys = append(keys, iter.Key())
+		vals = append(vals, iter.Value())
+	}
+	err := c.chunks(len(keys), func(w *copier, from, to int) error {
+		for i := from; i < to; i++ {
+			if err := w.tick(); err != nil {
+				return err
+			}
+			w.path.push(PathStep{Kind: KeyStep, Key: keys[i]})
+			redacted := w.redacted
+			kc, err := w.copyPlan(keys[i], p.key)
+			if err != nil || w.redacted != redacted {
+				w.path.pop()
+				// the entry of a redacted key is left out
+				keys[i] = reflect.Value{}
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			vc, err := w.copyPlan(vals[i], p.elem)
+			w.path.pop()
+			if err != nil {
+				return err
+			}
+			keys[i], vals[i] = kc, vc
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i := range keys {
+		if keys[i].IsValid() {
+			oc.SetMapIndex(keys[i], vals[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package deepcopy
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type entry struct {
+	ID    int
+	Tags  []string
+	Attrs map[string]*entry
+	Self  *entry
+}
+
+func newIndex(n int) map[string][]*entry {
+	shared := &entry{ID: -1, Tags: []string{"shared"}}
+	index := make(map[string][]*entry)
+	for i := 0; i < n; i++ {
+		e := &entry{ID: i, Tags: []string{"a", "b"}, Attrs: map[string]*entry{"shared": shared}}
+		e.Self = e
+		key := string(rune('a' + i%7))
+		index[key] = append(index[key], e, shared)
+	}
+	return index
+}
+
+func TestCopyParallel(t *testing.T) {
+	index := newIndex(1000)
+	v, err := Clone(index, WithParallel(4, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(index, v) {
+		t.Fatal("expected the parallel copy to equal the original")
+	}
+	var shared *entry
+	for _, entries := range v {
+		for i, e := range entries {
+			if i%2 == 1 {
+				if shared == nil {
+					shared = e
+				}
+				if e != shared || e == index["a"][1] {
+					t.Fatal("expected the shared entry to be copied once")
+				}
+				continue
+			}
+			if e == index["a"][0] || e.Self != e {
+				t.Fatalf("got: %+v, expected a copy keeping its cycle", e)
+			}
+			if e.Attrs["shared"] != entries[i+1] {
+				t.Fatal("expected the shared entry to be copied once")
+			}
+		}
+	}
+}
+
+func TestCopyParallelError(t *testing.T) {
+	type bad struct {
+		P uintptr
+	}
+	v := make([]*bad, 100)
+	v[30] = &bad{P: 1}
+	v[80] = &bad{P: 1}
+	for i := 0; i < 10; i++ {
+		var uerr *UnsupportedTypeError
+		if _, err := Clone(v, WithParallel(8, 2)); !errors.As(err, &uerr) || uerr.Path != "[30].P" {
+			t.Fatalf("got error: %v, expected the error of the first failing element", err)
+		}
+	}
+}
+
+func TestCopyParallelPanic(t *testing.T) {
+	v := make([]*cancelling, 100)
+	for i := range v {
+		v[i] = &cancelling{cancel: func() {}}
+	}
+	v[60].cancel = func() { panic("copy") }
+	defer func() {
+		if r := recover(); r != "copy" {
+			t.Fatalf("got panic: %v, expected the panic of the copy method", r)
+		}
+	}()
+	Clone(v, WithParallel(4, 2))
+}
+
+func BenchmarkCopyParallel(b *testing.B) {
+	index := newIndex(100000)
+	for _, workers := range []int{1, 4} {
+		b.Run(strconv.Itoa(workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := Clone(index, WithParallel(workers, 1000)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
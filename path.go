@@ -0,0 +1,69 @@
+package deepcopy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// StepKind says how a PathStep reaches a value.
+type StepKind int
+
+const (
+	// FieldStep reaches a struct field.
+	FieldStep StepKind = iota
+	// IndexStep reaches an element of a slice or an array, or an element buffered in a channel.
+	IndexStep
+	// KeyStep reaches a map entry.
+	KeyStep
+)
+
+// PathStep is one step on the way from the root to a value: a struct field, an index or a map key.
+// Pointers and interfaces are followed without a step.
+type PathStep struct {
+	Kind StepKind
+	// Name is the name of the field of a FieldStep.
+	Name string
+	// Index is the index of an IndexStep.
+	Index int
+	// Key is the key of a KeyStep.
+	Key reflect.Value
+}
+
+// Path leads from the root to a value.
+type Path []PathStep
+
+func (p *Path) push(s PathStep) {
+	*p = append(*p, s)
+}
+
+func (p *Path) pop() {
+	*p = (*p)[:len(*p)-1]
+}
+
+// String formats the path the way the value would be reached in Go, e.g. .Config.Handlers[3].ptr.
+func (p Path) String() string {
+	var b strings.Builder
+	for _, s := range p {
+		switch s.Kind {
+		case FieldStep:
+			b.WriteString(".")
+			b.WriteString(s.Name)
+		case IndexStep:
+			fmt.Fprintf(&b, "[%d]", s.Index)
+		case KeyStep:
+			b.WriteString("[")
+			b.WriteString(formatKey(s.Key))
+			b.WriteString("]")
+		}
+	}
+	return b.String()
+}
+
+// formatKey formats a map key, quoting strings.
+func formatKey(key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return fmt.Sprintf("%q", key.String())
+	}
+	return fmt.Sprintf("%v", key)
+}
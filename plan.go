@@ -0,0 +1,172 @@
+package deepcopy
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// strategy is the way the values of a type are copied, when they have no copier and no copy method.
+type strategy int
+
+const (
+	// assignStrategy returns the value itself, for types holding no references and for shared kinds.
+	assignStrategy strategy = iota
+	structStrategy
+	pointerStrategy
+	sliceStrategy
+	arrayStrategy
+	mapStrategy
+	interfaceStrategy
+	chanStrategy
+	funcStrategy
+	// syncMapStrategy copies the entries of a sync.Map, which keeps them behind unsafe pointers.
+	syncMapStrategy
+	unsupportedStrategy
+)
+
+var syncMapType = reflect.TypeOf(sync.Map{})
+
+// plan is what a copy needs to know about a type.
+// It is compiled once per type and cached, so the copy does not inspect the type again for every value.
+type plan struct {
+	typ      reflect.Type
+	strategy strategy
+	// copier is the registered copier of the type, if any.
+	copier CopierFunc
+	// method is the copy method of the type, if any.
+	method copyMethod
+	// lock is true for locks, which are reset or rejected instead of copied.
+	lock bool
+	// sensitive is true for the types registered with RegisterSensitive.
+	sensitive bool
+	// locker says how values are locked by LockSource.
+	locker lockerKind
+	// primitive is true for types holding no references, with no copier and no copy method,
+	// and for structs, with all fields exported and untagged.
+	// Their values are copied by assignment, and slices of them with a single copy.
+	primitive bool
+	// fields are the fields of a struct.
+	fields []fieldPlan
+	// key is the plan of the key of a map.
+	key *plan
+	// elem is the plan of the element of a pointer, slice, array, map or channel.
+	elem *plan
+}
+
+// fieldPlan is what a copy needs to know about a struct field.
+type fieldPlan struct {
+	index int
+	name  string
+	mode  tagMode
+	// alias is the name CopyTo matches the field by, set by the name tag option.
+	alias string
+	// exported is false for fields that cannot be set through reflection.
+	exported bool
+	// direct fields are exported primitive fields without a tag, assigned as they are.
+	direct bool
+	plan   *plan
+}
+
+// plans caches the compiled plan of a type.
+// It is replaced with an empty cache whenever a copier is registered.
+var plans atomic.Pointer[sync.Map]
+
+func init() {
+	plans.Store(new(sync.Map))
+}
+
+// Prepare compiles and caches what Copy needs to know about the specified types and the types they reference,
+// so the first copies of their values are as fast as the following ones.
+// Calling it is never required.
+func Prepare(types ...reflect.Type) {
+	for _, t := range types {
+		planFor(t)
+	}
+}
+
+// planFor returns the plan of t.
+func planFor(t reflect.Type) *plan {
+	if p, ok := plans.Load().Load(t); ok {
+		return p.(*plan)
+	}
+	// the read lock keeps registrations from replacing the cache until we are done
+	copiers.RLock()
+	defer copiers.RUnlock()
+	cache := plans.Load()
+	compiled := make(map[reflect.Type]*plan)
+	p := compile(t, cache, compiled)
+	for t, p := range compiled {
+		cache.LoadOrStore(t, p)
+	}
+	return p
+}
+
+// compile returns the plan of t, compiling the plans of the types it references as well.
+// compiled holds the plans compiled so far, including the unfinished ones of recursive types.
+func compile(t reflect.Type, cache *sync.Map, compiled map[reflect.Type]*plan) *plan {
+	if p, ok := cache.Load(t); ok {
+		return p.(*plan)
+	}
+	if p, ok := compiled[t]; ok {
+		return p
+	}
+	p := &plan{typ: t, copier: copierFor(t), method: findCopyMethod(t)}
+	compiled[t] = p
+	p.lock = isLock(t)
+	p.locker = findLocker(t)
+	p.sensitive = sensitive[t]
+	p.primitive = isPrimitive(t) && p.copier == nil && p.method.kind == noMethod && !p.lock && !p.sensitive
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == syncMapType {
+			p.strategy = syncMapStrategy
+			break
+		}
+		p.strategy = structStrategy
+		p.fields = make([]fieldPlan, t.NumField())
+		for i := range p.fields {
+			sf := t.Field(i)
+			fp := fieldPlan{
+				index:    i,
+				name:     sf.Name,
+				exported: sf.IsExported(),
+				plan:     compile(sf.Type, cache, compiled),
+			}
+			fp.mode, fp.alias = parseTag(sf.Tag)
+			fp.direct = fp.exported && fp.mode == tagNone && fp.plan.primitive
+			p.fields[i] = fp
+			p.primitive = p.primitive && fp.direct
+		}
+	case reflect.Ptr:
+		p.strategy = pointerStrategy
+		p.elem = compile(t.Elem(), cache, compiled)
+	case reflect.Slice:
+		p.strategy = sliceStrategy
+		p.elem = compile(t.Elem(), cache, compiled)
+	case reflect.Array:
+		p.strategy = arrayStrategy
+		p.elem = compile(t.Elem(), cache, compiled)
+		p.primitive = p.primitive && p.elem.primitive
+	case reflect.Map:
+		p.strategy = mapStrategy
+		p.key = compile(t.Key(), cache, compiled)
+		p.elem = compile(t.Elem(), cache, compiled)
+	case reflect.Interface:
+		p.strategy = interfaceStrategy
+	case reflect.Chan:
+		p.strategy = chanStrategy
+		p.elem = compile(t.Elem(), cache, compiled)
+	case reflect.Func:
+		p.strategy = funcStrategy
+	case reflect.Int, reflect.String, reflect.Int64, reflect.Float64, reflect.Bool, reflect.Uint, reflect.Uint64,
+		reflect.Float32,
+		reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Complex64, reflect.Complex128,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		p.strategy = assignStrategy
+	default:
+		p.strategy = unsupportedStrategy
+	}
+	return p
+}
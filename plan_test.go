@@ -0,0 +1,112 @@
+package deepcopy
+
+import (
+	"reflect"
+	"testing"
+)
+
+type tree struct {
+	Name     string
+	Children []*tree
+	Parent   *tree
+}
+
+func TestPrepare(t *testing.T) {
+	Prepare(reflect.TypeOf(tree{}))
+	p := planFor(reflect.TypeOf(tree{}))
+	if p.strategy != structStrategy || len(p.fields) != 3 {
+		t.Fatalf("got plan: %+v, expected a struct plan with 3 fields", p)
+	}
+	if !p.fields[0].direct || p.fields[1].direct {
+		t.Fatal("expected only the string field to be direct")
+	}
+	// the plan of the parent points back to the plan of the tree
+	if parent := p.fields[2].plan; parent.elem != p {
+		t.Fatalf("got plan: %+v, expected the plan of the parent to point back to the plan of the tree", parent.elem)
+	}
+	root := &tree{Name: "root"}
+	root.Children = []*tree{{Name: "child", Parent: root}}
+	v, err := Clone(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Children[0].Parent != v || v.Children[0].Name != "child" {
+		t.Fatalf("got: %+v, expected a copy of the tree", v)
+	}
+}
+
+type late int
+
+func TestRegisterCopierAfterCopy(t *testing.T) {
+	v, err := Clone([]late{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v[0] != 1 {
+		t.Fatalf("got: %d, expected: 1", v[0])
+	}
+	RegisterCopier(reflect.TypeOf(late(0)), func(ov reflect.Value) (reflect.Value, error) {
+		return reflect.ValueOf(late(2)), nil
+	})
+	v, err = Clone([]late{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v[0] != 2 {
+		t.Fatalf("got: %d, expected the copier registered later to be used", v[0])
+	}
+}
+
+func TestCopyPrimitive(t *testing.T) {
+	type point struct {
+		X, Y float64
+	}
+	type hidden struct {
+		X int
+		y int
+	}
+	tests := []struct {
+		typ       reflect.Type
+		primitive bool
+	}{
+		{reflect.TypeOf(point{}), true},
+		{reflect.TypeOf([4]point{}), true},
+		{reflect.TypeOf(hidden{}), false},
+		{reflect.TypeOf(struct{ C chan int }{}), false},
+		{reflect.TypeOf(struct {
+			X int `deepcopy:"-"`
+		}{}), false},
+		{reflect.TypeOf(late(0)), false},
+	}
+	for _, test := range tests {
+		if p := planFor(test.typ); p.primitive != test.primitive {
+			t.Fatalf("%s: got primitive: %t, expected: %t", test.typ, p.primitive, test.primitive)
+		}
+	}
+
+	points := []point{{1, 2}, {3, 4}}
+	vp, err := Clone(points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(vp, points) || &vp[0] == &points[0] {
+		t.Fatalf("got: %v, expected a copy of: %v", vp, points)
+	}
+	vh, err := Clone([]hidden{{X: 1, y: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vh[0].X != 1 || vh[0].y != 0 {
+		t.Fatalf("got: %+v, expected unexported field to be zero", vh[0])
+	}
+}
+
+var payload []byte
+
+func BenchmarkCopyBytes(b *testing.B) {
+	u := make([]byte, 10<<20)
+	b.SetBytes(int64(len(u)))
+	for i := 0; i < b.N; i++ {
+		payload = MustClone(u)
+	}
+}
@@ -0,0 +1,103 @@
+package deepcopy
+
+import (
+	"reflect"
+	"sync"
+)
+
+// sensitive holds the types registered with RegisterSensitive.
+// It is guarded by the lock of copiers, like everything plans are compiled from.
+var sensitive = make(map[reflect.Type]bool)
+
+// RegisterSensitive makes WithRedaction redact all values of type t, as if they were in fields tagged with deepcopy:"redact".
+// It is safe to call RegisterSensitive concurrently with Copy, but copies
+// already in progress might not see the new type.
+func RegisterSensitive(t reflect.Type) {
+	copiers.Lock()
+	defer copiers.Unlock()
+	sensitive[t] = true
+	// the plans compiled so far might miss the new type
+	plans.Store(new(sync.Map))
+}
+
+// WithRedaction makes the copy redact the fields tagged with deepcopy:"redact" and the values of the types
+// registered with RegisterSensitive, so the copy can be logged without leaking them.
+// Strings are replaced with placeholder, while numbers, booleans, channels and functions are left zero.
+// Slices and maps are left empty, and nil if they are nil.
+// Map entries whose key is redacted, or holds redacted values, are left out of the copy,
+// as redacted keys would collide and reveal the number of entries at best.
+// Pointers, interfaces, arrays and structs are copied, with everything they hold redacted,
+// except for unexported struct fields, which are left zero.
+// Without WithRedaction, the tag and the registered types are ignored.
+func WithRedaction(placeholder string) Option {
+	return func(o *options) {
+		o.redact = true
+		o.placeholder = placeholder
+	}
+}
+
+// redact returns the redacted copy of ov.
+func (c *copier) redact(ov reflect.Value) reflect.Value {
+	c.redacted++
+	r := redactor{placeholder: c.opts.placeholder, pointers: make(map[visit]reflect.Value)}
+	return r.redact(ov)
+}
+
+// redactor redacts a value.
+type redactor struct {
+	placeholder string
+	// pointers maps the redacted pointers to their copies, so cycles end.
+	pointers map[visit]reflect.Value
+}
+
+func (r *redactor) redact(ov reflect.Value) reflect.Value {
+	t := ov.Type()
+	switch ov.Kind() {
+	case reflect.String:
+		oc := reflect.New(t).Elem()
+		oc.SetString(r.placeholder)
+		return oc
+	case reflect.Slice:
+		if !ov.IsNil() {
+			return reflect.MakeSlice(t, 0, 0)
+		}
+	case reflect.Map:
+		if !ov.IsNil() {
+			return reflect.MakeMap(t)
+		}
+	case reflect.Ptr:
+		if ov.IsNil() {
+			break
+		}
+		key := visit{ptr: ov.Pointer(), typ: t}
+		if oc, ok := r.pointers[key]; ok {
+			return oc
+		}
+		oc := reflect.New(t.Elem())
+		r.pointers[key] = oc
+		oc.Elem().Set(r.redact(ov.Elem()))
+		return oc
+	case reflect.Interface:
+		if ov.IsNil() {
+			break
+		}
+		oc := reflect.New(t).Elem()
+		oc.Set(r.redact(ov.Elem()))
+		return oc
+	case reflect.Array:
+		oc := reflect.New(t).Elem()
+		for i := 0; i < ov.Len(); i++ {
+			oc.Index(i).Set(r.redact(ov.Index(i)))
+		}
+		return oc
+	case reflect.Struct:
+		oc := reflect.New(t).Elem()
+		for i := 0; i < ov.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				oc.Field(i).Set(r.redact(ov.Field(i)))
+			}
+		}
+		return oc
+	}
+	return reflect.Zero(t)
+}
@@ -0,0 +1,104 @@
+package deepcopy
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type token string
+
+type credentials struct {
+	User     string
+	Password string `deepcopy:"redact"`
+	Keys     []string
+	Next     *credentials
+}
+
+type request struct {
+	URL     string
+	Token   token
+	Auth    *credentials      `deepcopy:"redact"`
+	Headers map[string]string `deepcopy:"redact"`
+	Retries int               `deepcopy:"redact"`
+	Extra   interface{}       `deepcopy:"redact"`
+	body    []byte            `deepcopy:"redact"`
+}
+
+func init() {
+	RegisterSensitive(reflect.TypeOf(token("")))
+}
+
+func TestCopyRedaction(t *testing.T) {
+	auth := &credentials{User: "user", Password: "password", Keys: []string{"key"}}
+	auth.Next = auth
+	r := request{
+		URL:     "url",
+		Token:   "token",
+		Auth:    auth,
+		Headers: map[string]string{"Authorization": "secret"},
+		Retries: 3,
+		Extra:   credentials{User: "extra"},
+		body:    []byte("body"),
+	}
+
+	v, err := Clone(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Token != "token" || v.Auth.Password != "password" || v.Retries != 3 {
+		t.Fatalf("got: %+v, expected no redaction without WithRedaction", v)
+	}
+
+	v, err = Clone(r, WithRedaction("***"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := request{
+		URL:     "url",
+		Token:   "***",
+		Auth:    &credentials{User: "***", Password: "***", Keys: []string{}},
+		Headers: map[string]string{},
+		Extra:   credentials{User: "***", Password: "***"},
+		body:    []byte{},
+	}
+	expected.Auth.Next = expected.Auth
+	if diff := cmp.Diff(expected, v, cmp.AllowUnexported(request{})); diff != "" {
+		t.Fatal(diff)
+	}
+	if v.Auth.Next != v.Auth {
+		t.Fatal("expected the cycle to be kept")
+	}
+	c, err := Clone(*auth, WithRedaction("***"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.User != "user" || c.Password != "***" || c.Keys[0] != "key" {
+		t.Fatalf("got: %+v, expected only the tagged field to be redacted", c)
+	}
+}
+
+func TestCopyRedactionMapKeys(t *testing.T) {
+	m := map[token]int{"a": 1, "b": 2}
+	for _, opts := range [][]Option{{WithRedaction("***")}, {WithRedaction("***"), WithParallel(4, 1)}} {
+		v, err := Clone(m, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v == nil || len(v) != 0 {
+			t.Fatalf("got: %v, expected an empty map", v)
+		}
+	}
+	mixed := map[interface{}]int{token("a"): 1, "b": 2}
+	v, err := Clone(mixed, WithRedaction("***"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[interface{}]int{"b": 2}, v); diff != "" {
+		t.Fatal(diff)
+	}
+	if v, err := Clone(m); err != nil || len(v) != 2 {
+		t.Fatalf("got: %v, error: %v, expected the keys to be kept without WithRedaction", v, err)
+	}
+}
@@ -0,0 +1,224 @@
+package deepcopy
+
+import (
+	"reflect"
+	"sort"
+	"unsafe"
+)
+
+// SharedRef is memory referenced by two values, found by Shared.
+type SharedRef struct {
+	// PathA leads to the reference in the first value, and PathB to the one in the second.
+	PathA, PathB Path
+	// Kind is the kind of the reference: a pointer, a map, a slice, a channel or a function.
+	// Pointers and slices are shared when the memory they reference overlaps,
+	// like a pointer to an element of a slice, or to a field of a struct another pointer points to.
+	Kind reflect.Kind
+}
+
+// Shared returns the memory referenced by both a and b, e.g. to check that a copy is independent of the original.
+// Both values are walked entirely, unexported fields included, so the *time.Location of a time.Time is reported
+// when a and b use the same location.
+// Only the outermost shared references of b are reported, not the ones they lead to.
+// Pointers to values of zero size and slices with no capacity are not reported, as they may share memory
+// without referencing anything.
+func Shared(a, b interface{}) []SharedRef {
+	s := sharing{refs: make(map[visit]Path), walked: make(map[visit]bool)}
+	s.walk(reflect.ValueOf(a), s.record)
+	s.extents.index()
+	s.path = nil
+	s.walked = make(map[visit]bool)
+	s.walk(reflect.ValueOf(b), s.match)
+	return s.shared
+}
+
+// sharing holds the state of one Shared.
+type sharing struct {
+	path Path
+	// refs maps the channels and functions of the first value to their paths.
+	refs map[visit]Path
+	// extents are the memory the pointers, maps and slices of the first value reference.
+	extents spans
+	walked  map[visit]bool
+	shared  []SharedRef
+}
+
+// spans indexes ranges of memory, to find the ones overlapping another range.
+type spans struct {
+	list []span
+	// ends[i] is the span ending last among the first i+1 ones, once indexed
+	ends []int
+}
+
+// span is a range of memory, from start inclusive to end exclusive, found at the path.
+type span struct {
+	start, end uintptr
+	path       Path
+}
+
+func (s *spans) add(start, end uintptr, path Path) {
+	s.list = append(s.list, span{start: start, end: end, path: path})
+}
+
+// index prepares the spans added so far for overlap.
+func (s *spans) index() {
+	sort.Slice(s.list, func(i, j int) bool {
+		return s.list[i].start < s.list[j].start
+	})
+	if cap(s.ends) < len(s.list) {
+		s.ends = make([]int, len(s.list))
+	}
+	s.ends = s.ends[:len(s.list)]
+	for i := range s.list {
+		s.ends[i] = i
+		if i > 0 && s.list[s.ends[i-1]].end > s.list[i].end {
+			s.ends[i] = s.ends[i-1]
+		}
+	}
+}
+
+// overlap returns a span overlapping the range from start to end, if any.
+func (s *spans) overlap(start, end uintptr) (span, bool) {
+	// the last span starting before the end of the range, which ends the latest
+	i := sort.Search(len(s.list), func(i int) bool { return s.list[i].start >= end }) - 1
+	if i >= 0 && s.list[s.ends[i]].end > start {
+		return s.list[s.ends[i]], true
+	}
+	return span{}, false
+}
+
+// only reports whether the span from start to end is the only one overlapping that range, once indexed.
+func (s *spans) only(start, end uintptr) bool {
+	i := sort.Search(len(s.list), func(i int) bool { return s.list[i].start >= end }) - 1
+	if i < 0 || s.list[i].start != start || s.list[i].end != end {
+		return false
+	}
+	return i == 0 || s.list[s.ends[i-1]].end <= start
+}
+
+// walk calls visit with every reference v holds, and walks what the reference leads to if visit returns true.
+// The values visit gets are never read only, so the identity of a function can be read through its address.
+func (s *sharing) walk(v reflect.Value, visit func(v reflect.Value) bool) {
+	if !v.IsValid() || isPrimitive(v.Type()) {
+		// there are no references to walk
+		return
+	}
+	if (v.Kind() == reflect.Struct || v.Kind() == reflect.Array) && !v.CanAddr() {
+		// the fields and elements of an addressable value are addressable, unexported fields are exposed
+		v = addressable(v)
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		if v.IsNil() || !visit(v) {
+			return
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		s.walk(v.Elem(), visit)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			s.path.push(PathStep{Kind: FieldStep, Name: t.Field(i).Name})
+			s.walk(exposed(v.Field(i)), visit)
+			s.path.pop()
+		}
+	case reflect.Slice, reflect.Array:
+		if isPrimitive(v.Type().Elem()) {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			s.path.push(PathStep{Kind: IndexStep, Index: i})
+			s.walk(v.Index(i), visit)
+			s.path.pop()
+		}
+	case reflect.Map:
+		if isPrimitive(v.Type().Key()) && isPrimitive(v.Type().Elem()) {
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			k := iter.Key()
+			s.path.push(PathStep{Kind: KeyStep, Key: k})
+			s.walk(k, visit)
+			s.walk(iter.Value(), visit)
+			s.path.pop()
+		}
+	}
+}
+
+// reference returns the identity of the reference v, and reports whether it references anything.
+func reference(v reflect.Value) (visit, bool) {
+	key := visit{typ: v.Type()}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.Type().Elem().Size() == 0 {
+			return key, false
+		}
+		key.ptr = v.Pointer()
+	case reflect.Slice:
+		if v.Cap() == 0 {
+			return key, false
+		}
+		key.ptr, key.len, key.cap = v.Pointer(), v.Len(), v.Cap()
+	case reflect.Func:
+		// the code of a closure is shared by all its instances, the closure itself is what the func value points to
+		if !v.CanAddr() {
+			v = addressable(v)
+		}
+		key.ptr = uintptr(*(*unsafe.Pointer)(unsafe.Pointer(v.UnsafeAddr())))
+	default:
+		key.ptr = v.Pointer()
+	}
+	return key, true
+}
+
+// record records the reference v of the first value, and reports whether it was not walked yet.
+func (s *sharing) record(v reflect.Value) bool {
+	key, ok := reference(v)
+	if !ok {
+		return true
+	}
+	if s.walked[key] {
+		return false
+	}
+	s.walked[key] = true
+	path := append(Path(nil), s.path...)
+	if start, end, ok := extent(v); ok {
+		s.extents.add(start, end, path)
+		return true
+	}
+	key.typ = nil
+	if _, ok := s.refs[key]; !ok {
+		s.refs[key] = path
+	}
+	return true
+}
+
+// match reports the reference v of the second value if the first value holds it as well,
+// and reports whether what it leads to must be walked.
+func (s *sharing) match(v reflect.Value) bool {
+	key, ok := reference(v)
+	if !ok {
+		return true
+	}
+	if s.walked[key] {
+		return false
+	}
+	s.walked[key] = true
+	var pathA Path
+	found := false
+	if start, end, ok := extent(v); ok {
+		var a span
+		a, found = s.extents.overlap(start, end)
+		pathA = a.path
+	} else {
+		key.typ = nil
+		pathA, found = s.refs[key]
+	}
+	if !found {
+		return true
+	}
+	s.shared = append(s.shared, SharedRef{PathA: pathA, PathB: append(Path(nil), s.path...), Kind: v.Kind()})
+	return false
+}
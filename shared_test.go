@@ -0,0 +1,97 @@
+package deepcopy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type pipeline struct {
+	Name    string
+	Stages  []*pipeline
+	Env     map[string]string
+	Input   chan int
+	Run     func() error
+	Started time.Time
+	Parent  *pipeline
+	Buffer  []byte `deepcopy:"shallow"`
+	Empty   *struct{}
+}
+
+func TestShared(t *testing.T) {
+	loc := time.FixedZone("zone", 3600)
+	p := &pipeline{
+		Name:    "p",
+		Stages:  []*pipeline{{Name: "a"}, {Name: "b"}},
+		Env:     map[string]string{"k": "v"},
+		Input:   make(chan int),
+		Run:     func() error { return nil },
+		Started: time.Date(2020, 1, 1, 0, 0, 0, 0, loc),
+		Buffer:  make([]byte, 4, 8),
+		Empty:   new(struct{}),
+	}
+	p.Stages[0].Parent = p
+	c := MustClone(p)
+	c.Empty = new(struct{})
+
+	got := make(map[string]reflect.Kind)
+	for _, ref := range Shared(p, c) {
+		if ref.PathA.String() != ref.PathB.String() {
+			t.Fatalf("got shared reference at %s and %s, expected the same path", ref.PathA, ref.PathB)
+		}
+		got[ref.PathB.String()] = ref.Kind
+	}
+	expected := map[string]reflect.Kind{
+		".Input":       reflect.Chan,
+		".Run":         reflect.Func,
+		".Started.loc": reflect.Ptr,
+		".Buffer":      reflect.Slice,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got shared: %v, expected: %v", got, expected)
+	}
+
+	if refs := Shared(p, p); len(refs) != 1 || refs[0].Kind != reflect.Ptr || len(refs[0].PathB) != 0 {
+		t.Fatalf("got shared: %v, expected only the value itself", refs)
+	}
+
+	// backing arrays overlapping up to their capacity are shared even when the slices differ
+	s := []int{1, 2, 3, 4}
+	refs := Shared(struct{ A, B []int }{s[:2:2], s[3:]}, s[1:2:3])
+	if len(refs) != 1 || refs[0].PathA.String() != ".A" || refs[0].Kind != reflect.Slice {
+		t.Fatalf("got shared: %v, expected the overlapping slice", refs)
+	}
+	if refs := Shared(s[:1:1], s[2:]); len(refs) != 0 {
+		t.Fatalf("got shared: %v, expected the slices not to overlap", refs)
+	}
+
+	// pointers into the memory of the other value are shared as well
+	x := &struct{ A, B int }{}
+	for _, test := range []struct {
+		a, b interface{}
+		kind reflect.Kind
+	}{
+		{s, &s[1], reflect.Ptr},
+		{&s[1], s, reflect.Slice},
+		{x, struct{ P *int }{&x.B}, reflect.Ptr},
+	} {
+		if refs := Shared(test.a, test.b); len(refs) != 1 || refs[0].Kind != test.kind {
+			t.Fatalf("got shared: %v, expected the %s into the other value", refs, test.kind)
+		}
+	}
+	if refs := Shared(&x.A, &x.B); len(refs) != 0 {
+		t.Fatalf("got shared: %v, expected the fields not to overlap", refs)
+	}
+}
+
+func TestSharedUnexportedFunc(t *testing.T) {
+	type S struct {
+		A int
+		f func()
+	}
+	f := func() {}
+	refs := Shared(S{A: 1, f: f}, map[string]S{"s": {f: f}})
+	if len(refs) != 1 || refs[0].PathA.String() != ".f" || refs[0].PathB.String() != `["s"].f` || refs[0].Kind != reflect.Func {
+		t.Fatalf("got shared: %v, expected the function", refs)
+	}
+}
@@ -0,0 +1,49 @@
+package deepcopy
+
+import (
+	"reflect"
+	"strings"
+)
+
+// tagMode is the way a struct field is copied, as set by its deepcopy tag.
+type tagMode int
+
+const (
+	// tagNone copies the field according to its kind.
+	tagNone tagMode = iota
+	// tagSkip, set by deepcopy:"-", leaves the field zero.
+	tagSkip
+	// tagShallow, set by deepcopy:"shallow", assigns the field without copying what it references.
+	tagShallow
+	// tagDeep, set by deepcopy:"deep", deep copies the field even if its kind is normally shared.
+	// A channel is copied into a new channel with copies of the buffered elements,
+	// while a function cannot be copied and causes a *ValueError.
+	tagDeep
+	// tagRedact, set by deepcopy:"redact", redacts the field when copying with WithRedaction.
+	tagRedact
+)
+
+// parseTag returns the copy mode of a struct field with the tag, and the name CopyTo matches it by, if any.
+// The tag holds comma separated options, like deepcopy:"shallow,name=Other".
+// Unknown values are ignored.
+// The tag applies to unexported fields as well, regardless of WithUnexported.
+func parseTag(tag reflect.StructTag) (tagMode, string) {
+	mode, name := tagNone, ""
+	for _, opt := range strings.Split(tag.Get("deepcopy"), ",") {
+		switch opt {
+		case "-":
+			mode = tagSkip
+		case "shallow":
+			mode = tagShallow
+		case "deep":
+			mode = tagDeep
+		case "redact":
+			mode = tagRedact
+		default:
+			if n, ok := strings.CutPrefix(opt, "name="); ok {
+				name = n
+			}
+		}
+	}
+	return mode, name
+}
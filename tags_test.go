@@ -0,0 +1,85 @@
+package deepcopy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCopyTags(t *testing.T) {
+	type S struct {
+		Skipped  *int           `deepcopy:"-"`
+		Table    map[string]int `deepcopy:"shallow"`
+		Renamed  []int          `deepcopy:"shallow,name=Other"`
+		Jobs     chan *int      `deepcopy:"deep"`
+		Results  <-chan int     `deepcopy:"deep"`
+		Shared   chan int
+		state    []int `deepcopy:"deep"`
+		instance []int `deepcopy:"shallow"`
+	}
+	i := 1
+	jobs := make(chan *int, 3)
+	jobs <- &i
+	results := make(chan int, 2)
+	results <- 1
+	results <- 2
+	u := S{
+		Skipped:  &i,
+		Table:    map[string]int{"a": 1},
+		Renamed:  []int{3},
+		Jobs:     jobs,
+		Results:  results,
+		Shared:   make(chan int),
+		state:    []int{1},
+		instance: []int{2},
+	}
+	vi, err := Copy(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := vi.(S)
+	if v.Skipped != nil {
+		t.Fatalf("got: %v, expected skipped field to be nil", v.Skipped)
+	}
+	v.Table["b"] = 2
+	if len(u.Table) != 2 {
+		t.Fatal("shallow map is not shared with the original")
+	}
+	if v.Jobs == u.Jobs || cap(v.Jobs) != 3 || len(v.Jobs) != 1 || len(u.Jobs) != 1 {
+		t.Fatalf("got channel with len: %d, cap: %d, expected a new channel with len 1 and cap 3", len(v.Jobs), cap(v.Jobs))
+	}
+	if j := <-v.Jobs; j == &i || *j != 1 {
+		t.Fatalf("got: %v, expected a copy of the buffered pointer", j)
+	}
+	if len(v.Results) != 2 || <-v.Results != 1 || <-v.Results != 2 {
+		t.Fatal("buffered elements were not copied in order")
+	}
+	if len(u.Results) != 2 || <-u.Results != 1 || <-u.Results != 2 {
+		t.Fatal("buffered elements were not restored in order")
+	}
+	if v.Shared != u.Shared {
+		t.Fatal("channel without tag is not shared with the original")
+	}
+	if &v.state[0] == &u.state[0] || v.state[0] != 1 {
+		t.Fatalf("got: %v, expected a deep copy of unexported field", v.state)
+	}
+	if &v.instance[0] != &u.instance[0] {
+		t.Fatalf("got: %v, expected a shallow copy of unexported field", v.instance)
+	}
+	if &v.Renamed[0] != &u.Renamed[0] {
+		t.Fatalf("got: %v, expected a shallow copy of the field with several tag options", v.Renamed)
+	}
+}
+
+func TestCopyTagDeepFunc(t *testing.T) {
+	type S struct {
+		F func() `deepcopy:"deep"`
+	}
+	_, err := Copy(S{F: func() {}})
+	var ve *ValueError
+	if !errors.As(err, &ve) {
+		t.Fatalf("got error: %v, expected *ValueError", err)
+	}
+	if ve.Path != ".F" {
+		t.Fatalf("got path: %s, expected: .F", ve.Path)
+	}
+}
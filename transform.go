@@ -0,0 +1,45 @@
+package deepcopy
+
+import (
+	"errors"
+	"reflect"
+)
+
+// TransformFunc is called with every value the copy reaches, before copying it, and with the path leading to it.
+// When it reports the value as handled, the replacement is used as the copy of the value, as it is,
+// and the values it holds are not reached.
+// The replacement must be assignable to the type of the value, and an invalid replacement leaves the zero value.
+// When it does not handle the value, the value is copied as usual.
+// The path is only valid during the call.
+type TransformFunc func(path Path, v reflect.Value) (replacement reflect.Value, handled bool, err error)
+
+// WithTransform calls f with every value the copy reaches, so it can replace them as part of the copy,
+// e.g. to convert every time.Time to UTC.
+// Values holding no references are reached one by one as well, so copies calling f are slower.
+// With WithParallel, f is called concurrently.
+func WithTransform(f TransformFunc) Option {
+	return func(o *options) {
+		o.transform = f
+	}
+}
+
+// errInvalidReplacement is reported when a TransformFunc returns a value that cannot be assigned to the copied type.
+var errInvalidReplacement = errors.New("transform returned a value of a wrong type")
+
+// transform calls the TransformFunc of the copy with ov, and returns the replacement if ov was handled.
+func (c *copier) transform(ov reflect.Value) (reflect.Value, bool, error) {
+	oc, handled, err := c.opts.transform(c.path, ov)
+	if err != nil {
+		return reflect.Value{}, false, &TransformError{Type: ov.Type(), Path: c.path.String(), Err: err}
+	}
+	if !handled {
+		return reflect.Value{}, false, nil
+	}
+	if !oc.IsValid() {
+		return reflect.Zero(ov.Type()), true, nil
+	}
+	if !oc.Type().AssignableTo(ov.Type()) {
+		return reflect.Value{}, false, &TransformError{Type: ov.Type(), Path: c.path.String(), Err: errInvalidReplacement}
+	}
+	return oc, true, nil
+}
@@ -0,0 +1,122 @@
+package deepcopy
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type event struct {
+	Name   string
+	At     time.Time
+	Tags   []string
+	Attrs  map[string]string
+	Secret *string
+	Point  struct{ X, Y int }
+}
+
+func TestCopyTransform(t *testing.T) {
+	secret := "secret"
+	loc := time.FixedZone("X", 3600)
+	e := event{
+		Name:   " event ",
+		At:     time.Date(2020, 1, 1, 12, 0, 0, 0, loc),
+		Tags:   []string{" a", "b "},
+		Attrs:  map[string]string{"k": " v "},
+		Secret: &secret,
+	}
+	e.Point.X = 1
+	var paths []string
+	transform := func(path Path, v reflect.Value) (reflect.Value, bool, error) {
+		paths = append(paths, path.String())
+		switch x := v.Interface().(type) {
+		case time.Time:
+			return reflect.ValueOf(x.UTC()), true, nil
+		case string:
+			return reflect.ValueOf(strings.TrimSpace(x)), true, nil
+		case *string:
+			// drop the secret
+			return reflect.Value{}, true, nil
+		case int:
+			if len(path) > 0 && path[len(path)-1].Name == "X" {
+				return reflect.ValueOf(x + 1), true, nil
+			}
+		}
+		return reflect.Value{}, false, nil
+	}
+	v, err := Clone(e, WithTransform(transform))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := event{
+		Name:  "event",
+		At:    e.At.UTC(),
+		Tags:  []string{"a", "b"},
+		Attrs: map[string]string{"k": "v"},
+	}
+	expected.Point.X = 2
+	if diff := cmp.Diff(expected, v); diff != "" {
+		t.Fatal(diff)
+	}
+	if v.At.Location() != time.UTC {
+		t.Fatalf("got location: %s, expected UTC", v.At.Location())
+	}
+	for _, p := range []string{"", ".Name", ".Tags[1]", `.Attrs["k"]`, ".Point.Y"} {
+		if !contains(paths, p) {
+			t.Errorf("expected the transform to be called at %q, got: %q", p, paths)
+		}
+	}
+}
+
+func contains(paths []string, p string) bool {
+	for _, path := range paths {
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCopyTransformErrors(t *testing.T) {
+	errBad := errors.New("bad")
+	failing := func(path Path, v reflect.Value) (reflect.Value, bool, error) {
+		if v.Kind() == reflect.String {
+			return reflect.Value{}, false, errBad
+		}
+		return reflect.Value{}, false, nil
+	}
+	var terr *TransformError
+	if _, err := Clone(event{Tags: []string{"a"}}, WithTransform(failing)); !errors.As(err, &terr) || terr.Path != ".Name" || !errors.Is(err, errBad) {
+		t.Fatalf("got error: %v, expected a *TransformError at .Name", err)
+	}
+	wrong := func(path Path, v reflect.Value) (reflect.Value, bool, error) {
+		if v.Kind() == reflect.String {
+			return reflect.ValueOf(1), true, nil
+		}
+		return reflect.Value{}, false, nil
+	}
+	if _, err := Clone(event{}, WithTransform(wrong)); !errors.As(err, &terr) || !errors.Is(err, errInvalidReplacement) {
+		t.Fatalf("got error: %v, expected a *TransformError for the wrong type", err)
+	}
+}
+
+func TestCopyIntoTransform(t *testing.T) {
+	src := []string{" a ", " b "}
+	dst := make([]string, 0, 2)
+	upper := func(path Path, v reflect.Value) (reflect.Value, bool, error) {
+		if v.Kind() == reflect.String {
+			return reflect.ValueOf(strings.ToUpper(strings.TrimSpace(v.String()))), true, nil
+		}
+		return reflect.Value{}, false, nil
+	}
+	if err := CopyInto(&dst, src, WithTransform(upper)); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"A", "B"}, dst); diff != "" {
+		t.Fatal(diff)
+	}
+}